@@ -0,0 +1,190 @@
+// Package accio provides an API to load and run generators from Go code.
+//
+// A generator is loaded from a file tree, e.g. a local directory or a git
+// repository fetched with gitgetter package, and can be run any number of
+// times with different answers to its prompts:
+//
+//	gen, err := accio.Load(accio.DirTree(afero.NewOsFs(), "./generator"))
+//	if err != nil {
+//		return err
+//	}
+//	answers := map[string]interface{}{"name": "service"}
+//	err = gen.Run(ctx, answers, afero.Afero{Fs: afero.NewOsFs()}, accio.WorkingDir("./project"))
+package accio
+
+import (
+	"context"
+	"github.com/spf13/afero"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/fs"
+	"github.com/g1ntas/accio/internal/manifest"
+	"github.com/g1ntas/accio/internal/setup"
+)
+
+// ManifestFilename is the name of the manifest file at the root of the generator.
+const ManifestFilename = setup.ManifestFilename
+
+// Prompter asks the user for answers to prompts of the generator. Each method
+// prompts for a value of the corresponding prompt type, with given message,
+// help text and default value, and validator, if any, checks the answer.
+// CLI prompter from prompter package can be used to prompt in terminal.
+type Prompter interface {
+	Get(message, help, defaultVal string, validator func(val string) error) (string, error)
+	SelectOne(message, help string, options []string, defaultVal string) (string, error)
+	SelectMultiple(message, help string, options []string, defaultVal []string, validator func(val []string) error) ([]string, error)
+	Confirm(message, help string, defaultVal bool) (bool, error)
+	Password(message, help string, validator func(val string) error) (string, error)
+	// GetList prompts for multiple entries until an empty entry is submitted.
+	GetList(message, help string, defaultVal []string, validator func(val string) error) ([]string, error)
+	// GetPath prompts for a filesystem path, suggesting completions for
+	// paths relative to given directory.
+	GetPath(message, help, defaultVal, dir string, validator func(val string) error) (string, error)
+}
+
+// Generator is a generator loaded from a file tree.
+type Generator struct {
+	tree generator.FileTreeReader
+}
+
+// DirTree returns a file tree of the directory within given filesystem.
+func DirTree(base afero.Fs, dir string) generator.FileTreeReader {
+	return fs.NewAferoFileTreeReader(base, dir)
+}
+
+// Load reads and validates the manifest of the generator within given file tree.
+func Load(tree generator.FileTreeReader) (*Generator, error) {
+	g := &Generator{tree: tree}
+	if _, err := g.manifest(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// manifest reads manifest of the generator. Manifest is read on each run,
+// since prompts keep state, like the working directory of path prompts.
+func (g *Generator) manifest() (*manifest.Generator, error) {
+	return setup.ReadManifest(g.tree)
+}
+
+// Help returns the help text of the generator.
+func (g *Generator) Help() (string, error) {
+	m, err := g.manifest()
+	if err != nil {
+		return "", err
+	}
+	return m.Help, nil
+}
+
+// Prompts returns keys of the prompts of the generator in the order they are declared.
+func (g *Generator) Prompts() ([]string, error) {
+	m, err := g.manifest()
+	if err != nil {
+		return nil, err
+	}
+	return m.PromptKeys(), nil
+}
+
+type runConfig struct {
+	prompter   Prompter
+	log        generator.Logger
+	workingDir string
+	strict     bool
+	options    []generator.OptionFn
+}
+
+// Option configures a run of the generator.
+type Option func(*runConfig)
+
+// WithPrompter prompts the user for answers, which are not provided.
+// Without prompter, default values are used for missing answers, and
+// missing answers without default values and answers for unknown
+// prompts are reported as errors.
+func WithPrompter(p Prompter) Option {
+	return func(c *runConfig) {
+		c.prompter = p
+	}
+}
+
+// WithLogger sets logger for debug information and errors of skipped files.
+func WithLogger(l generator.Logger) Option {
+	return func(c *runConfig) {
+		c.log = l
+	}
+}
+
+// WorkingDir sets the directory within filesystem to write generated files to.
+func WorkingDir(dir string) Option {
+	return func(c *runConfig) {
+		c.workingDir = dir
+	}
+}
+
+// NonStrict makes blueprints ignore unknown tags and attributes, duplicate
+// tags and missing names, instead of failing, which is the default.
+func NonStrict(c *runConfig) {
+	c.strict = false
+}
+
+// WithRunnerOptions passes options to generator.Runner, e.g. to handle
+// existing files with generator.OnFileExists, listen to generator.OnEvent,
+// or merge changes into existing files with generator.MergeChanges.
+func WithRunnerOptions(options ...generator.OptionFn) Option {
+	return func(c *runConfig) {
+		c.options = append(c.options, options...)
+	}
+}
+
+// Run generates files of the generator into given filesystem with given
+// answers to prompts. Existing files are skipped, unless handled otherwise
+// with generator.OnFileExists runner option. Run stops before the next file
+// is generated, once ctx is done.
+func (g *Generator) Run(ctx context.Context, answers map[string]interface{}, fs generator.Filesystem, opts ...Option) error {
+	c := &runConfig{log: generator.NopLogger{}, strict: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m, err := g.manifest()
+	if err != nil {
+		return err
+	}
+	m.SetWorkingDir(c.workingDir)
+	var data map[string]interface{}
+	if c.prompter != nil {
+		data, err = m.PromptMissing(c.prompter, answers)
+	} else {
+		data, err = m.AnswerAll(answers)
+	}
+	if err != nil {
+		return err
+	}
+	if err = m.ComputeVariables(data); err != nil {
+		return err
+	}
+	parser, err := blueprint.NewParser(data, c.log, setup.ParserOptions(g.tree, c.strict)...)
+	if err != nil {
+		return err
+	}
+	options := append(setup.RunnerOptions(m, parser, c.log), c.options...)
+	runner := generator.NewRunner(fs, parser, c.workingDir, options...)
+	return runner.Run(contextTree{g.tree, ctx})
+}
+
+// contextTree stops walking the file tree, once context is done.
+type contextTree struct {
+	generator.FileTreeReader
+	ctx context.Context
+}
+
+func (t contextTree) Walk(walkFn func(filepath string, isDir bool, err error) error) error {
+	return t.FileTreeReader.Walk(func(filepath string, isDir bool, err error) error {
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		return walkFn(filepath, isDir, err)
+	})
+}
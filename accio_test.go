@@ -0,0 +1,172 @@
+package accio
+
+import (
+	"context"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/prompter"
+)
+
+var _ Prompter = (*prompter.CLI)(nil)
+
+const testManifest = `
+help = "Test generator"
+
+[prompts.name]
+type = "input"
+message = "Name:"
+
+[prompts.greeting]
+type = "input"
+message = "Greeting:"
+default = "Hello"
+`
+
+type inputPrompter struct {
+	answers map[string]string
+}
+
+func (p *inputPrompter) Get(message, _, _ string, validator func(val string) error) (string, error) {
+	val := p.answers[message]
+	return val, validator(val)
+}
+
+func (p *inputPrompter) SelectOne(_, _ string, _ []string, _ string) (string, error) {
+	return "", nil
+}
+
+func (p *inputPrompter) SelectMultiple(_, _ string, _ []string, _ []string, _ func(val []string) error) ([]string, error) {
+	return nil, nil
+}
+
+func (p *inputPrompter) Confirm(_, _ string, _ bool) (bool, error) {
+	return false, nil
+}
+
+func (p *inputPrompter) Password(_, _ string, _ func(val string) error) (string, error) {
+	return "", nil
+}
+
+func (p *inputPrompter) GetList(_, _ string, _ []string, _ func(val string) error) ([]string, error) {
+	return nil, nil
+}
+
+func (p *inputPrompter) GetPath(message, _, _, _ string, validator func(val string) error) (string, error) {
+	return p.Get(message, "", "", validator)
+}
+
+func testGenerator(t *testing.T, files map[string]string) *Generator {
+	base := afero.NewMemMapFs()
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(base, "/gen/"+path, []byte(content), 0644))
+	}
+	gen, err := Load(DirTree(base, "/gen"))
+	require.NoError(t, err)
+	return gen
+}
+
+func readOutput(t *testing.T, fs afero.Afero, path string) string {
+	b, err := fs.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(DirTree(afero.NewMemMapFs(), "/gen"))
+	require.Error(t, err)
+
+	gen := testGenerator(t, map[string]string{ManifestFilename: testManifest})
+	help, err := gen.Help()
+	require.NoError(t, err)
+	require.Equal(t, "Test generator", help)
+	prompts, err := gen.Prompts()
+	require.NoError(t, err)
+	require.Equal(t, []string{"name", "greeting"}, prompts)
+}
+
+func TestRun(t *testing.T) {
+	gen := testGenerator(t, map[string]string{
+		ManifestFilename:  testManifest,
+		"hello.txt.accio": "template <<{{greeting}}, {{name}}!>>",
+		"static.txt":      "static",
+	})
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	err := gen.Run(context.Background(), map[string]interface{}{"name": "Accio"}, fs, WorkingDir("/out"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello, Accio!", readOutput(t, fs, "/out/hello.txt"))
+	require.Equal(t, "static", readOutput(t, fs, "/out/static.txt"))
+	exists, err := fs.Exists("/out/" + ManifestFilename)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestRunWithMissingAnswers(t *testing.T) {
+	gen := testGenerator(t, map[string]string{
+		ManifestFilename:  testManifest,
+		"hello.txt.accio": "template <<{{greeting}}, {{name}}!>>",
+	})
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	err := gen.Run(context.Background(), nil, fs, WorkingDir("/out"))
+	require.Error(t, err)
+
+	p := &inputPrompter{answers: map[string]string{"Name:": "Accio", "Greeting:": "Hi"}}
+	err = gen.Run(context.Background(), nil, fs, WorkingDir("/out"), WithPrompter(p))
+	require.NoError(t, err)
+	require.Equal(t, "Hi, Accio!", readOutput(t, fs, "/out/hello.txt"))
+}
+
+func TestRunStrict(t *testing.T) {
+	gen := testGenerator(t, map[string]string{
+		ManifestFilename:  testManifest,
+		"hello.txt.accio": "unknown << 1 >>\ntemplate <<{{name}}>>",
+	})
+	answers := map[string]interface{}{"name": "Accio"}
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	err := gen.Run(context.Background(), answers, fs, WorkingDir("/out"))
+	require.Error(t, err)
+
+	err = gen.Run(context.Background(), answers, fs, WorkingDir("/out"), NonStrict)
+	require.NoError(t, err)
+	require.Equal(t, "Accio", readOutput(t, fs, "/out/hello.txt"))
+}
+
+func TestRunWithRunnerOptions(t *testing.T) {
+	gen := testGenerator(t, map[string]string{
+		ManifestFilename: testManifest,
+		"a.txt":          "a",
+		"b.txt":          "b",
+	})
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/out/b.txt", []byte("existing"), 0644))
+	var events []generator.EventType
+	err := gen.Run(
+		context.Background(),
+		map[string]interface{}{"name": "Accio"},
+		fs,
+		WorkingDir("/out"),
+		WithRunnerOptions(generator.OnEvent(func(e generator.Event) {
+			events = append(events, e.Type)
+		})),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []generator.EventType{generator.FileIgnored, generator.FileCreated, generator.FileSkippedExisting}, events)
+	require.Equal(t, "existing", readOutput(t, fs, "/out/b.txt"))
+}
+
+func TestRunCancelled(t *testing.T) {
+	gen := testGenerator(t, map[string]string{
+		ManifestFilename: testManifest,
+		"a.txt":          "a",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	err := gen.Run(ctx, map[string]interface{}{"name": "Accio"}, fs, WorkingDir("/out"))
+	require.Equal(t, context.Canceled, err)
+	exists, err := fs.Exists("/out/a.txt")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
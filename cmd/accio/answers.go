@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+
+	"github.com/g1ntas/accio/internal/manifest"
+)
+
+// collectData gathers data for generator either by prompting the user or,
+// if answers were provided with --answers or --set flags, non-interactively
+// from the provided answers.
+func collectData(cmd *cobra.Command, gen *manifest.Generator) (map[string]interface{}, error) {
+	if !isNonInteractive(cmd) {
+		return gen.PromptAll(env.prompter)
+	}
+	env.log.Debug("running in non-interactive mode")
+	answers, err := readAnswers(getStringFlag(cmd, "answers"), getStringArrayFlag(cmd, "set"))
+	if err != nil {
+		return nil, err
+	}
+	return gen.AnswerAll(answers)
+}
+
+// isNonInteractive reports whether answers were provided with flags
+// and the user must not be prompted.
+func isNonInteractive(cmd *cobra.Command) bool {
+	return getStringFlag(cmd, "answers") != "" || len(getStringArrayFlag(cmd, "set")) > 0
+}
+
+// readAnswers reads answers from a file at given path, if path is not
+// empty, and applies overrides specified in key=value format on top of them.
+func readAnswers(path string, overrides []string) (map[string]interface{}, error) {
+	answers := make(map[string]interface{})
+	if path != "" {
+		b, err := env.fs.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading answers: %w", err)
+		}
+		if answers, err = decodeAnswers(b, filepath.Ext(path)); err != nil {
+			return nil, fmt.Errorf("parsing answers: %w", err)
+		}
+	}
+	for _, o := range overrides {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid answer %q, expected format is key=value", o)
+		}
+		answers[kv[0]] = kv[1]
+	}
+	return answers, nil
+}
+
+// decodeAnswers decodes answers from TOML, JSON or YAML document, depending on given file extension.
+func decodeAnswers(b []byte, ext string) (map[string]interface{}, error) {
+	answers := make(map[string]interface{})
+	switch strings.ToLower(ext) {
+	case ".toml":
+		if err := toml.Unmarshal(b, &answers); err != nil {
+			return nil, err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&answers); err != nil {
+			return nil, err
+		}
+		for k, v := range answers {
+			answers[k] = normalizeNumber(v)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &answers); err != nil {
+			return nil, err
+		}
+		for k, v := range answers {
+			answers[k] = normalizeNumber(v)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file format %q, expected .toml, .json, .yaml or .yml", ext)
+	}
+	return answers, nil
+}
+
+// normalizeNumber converts json numbers and integers within value into
+// int64 or float64, same as numbers decoded from TOML.
+func normalizeNumber(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return int64(val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		for i := range val {
+			val[i] = normalizeNumber(val[i])
+		}
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = normalizeNumber(val[k])
+		}
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"path/filepath"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/logger"
+)
+
+// errChangesPending is returned when previewed run would change the working directory.
+var errChangesPending = errors.New("changes are pending")
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [generator]",
+	Short: "Show changes a generator would make without writing them",
+	Long: `Runs generator without writing to filesystem and prints
+changes it would make to the working directory: unified diffs
+of modified files and a list of new files. Existing files are
+compared with the generated content, as if they would be
+overwritten. Exits with non-zero status if there are pending
+changes, so it can be used to detect drift in CI.
+
+Generator location and prompt answers are accepted the same
+way as in 'accio run' command. Alternatively, a run can be
+previewed with --dry and --diff flags of 'accio run' command,
+in which case existing files are handled as usual and the
+skipped files are listed as well.
+Examples:
+  accio diff ./generator --answers answers.toml
+  accio run ./generator --dry --diff
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		treeReader, gen, err := fetchGenerator(args[0])
+		if err != nil {
+			return err
+		}
+		writeDir, err := workingDir(cmd)
+		if err != nil {
+			return err
+		}
+		env.log.Debug("working directory: ", writeDir)
+		gen.SetWorkingDir(writeDir)
+		data, err := collectData(cmd, gen)
+		if err != nil {
+			return err
+		}
+		if err = gen.ComputeVariables(data); err != nil {
+			return err
+		}
+		parser, err := blueprint.NewParser(data, logger.NewFromLogger(env.log, "blueprint"), parserOptions(cmd, treeReader)...)
+		if err != nil {
+			return err
+		}
+		options := append(runnerOptions(gen, parser), generator.OnFileExists(func(_ string) bool {
+			return true
+		}))
+		if getBoolFlag(cmd, "ignore-errors") {
+			options = append(options, generator.SkipErrors)
+		}
+		rec := generator.NewRecorder(dryFilesystem())
+		runner := generator.NewRunner(rec, parser, writeDir, options...)
+		if err = runner.Run(treeReader); err != nil {
+			return err
+		}
+		return printChanges(cmd.OutOrStdout(), writeDir, rec.Changes(), nil)
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("answers", "", "Read prompt answers from a TOML, JSON or YAML file instead of prompting")
+	diffCmd.Flags().BoolP("ignore-errors", "i", false, "Ignore errors for files being generated")
+	diffCmd.Flags().StringArray("set", []string{}, "Set prompt answer in key=value format, can be used multiple times")
+	diffCmd.Flags().Bool("strict", true, "Fail on unknown tags and attributes, duplicate tags and missing names in blueprints")
+	diffCmd.Flags().StringP("working-dir", "w", "", "Specify working directory")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// skippedFileHandler wraps handler of existing files, recording paths of the skipped files.
+func skippedFileHandler(fn generator.OnExistsFn, skipped *[]string) generator.OnExistsFn {
+	return func(path string) bool {
+		if fn(path) {
+			return true
+		}
+		*skipped = append(*skipped, path)
+		return false
+	}
+}
+
+// printChanges prints unified diffs of modified files, and lists new
+// and skipped files. It returns errChangesPending, if any file would
+// be created or modified.
+func printChanges(w io.Writer, dir string, changes []*generator.Change, skipped []string) error {
+	var created []string
+	pending := false
+	for _, c := range changes {
+		switch {
+		case !c.Exists:
+			created = append(created, c.Path)
+		case c.Modified():
+			fmt.Fprint(w, generator.UnifiedDiff(relPath(dir, c.Path), c.Old, c.New))
+		default:
+			continue
+		}
+		pending = true
+	}
+	printPaths(w, dir, "New files:", created)
+	printPaths(w, dir, "Skipped files:", skipped)
+	if pending {
+		return errChangesPending
+	}
+	fmt.Fprintln(w, "No changes.")
+	return nil
+}
+
+func printPaths(w io.Writer, dir, title string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Fprintln(w, title)
+	for _, p := range paths {
+		fmt.Fprintln(w, "  "+relPath(dir, p))
+	}
+}
+
+// relPath returns path relative to the directory in Unix-like format.
+func relPath(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -17,3 +17,11 @@ func getStringFlag(cmd *cobra.Command, name string) string {
 	}
 	return val
 }
+
+func getStringArrayFlag(cmd *cobra.Command, name string) []string {
+	val, err := cmd.Flags().GetStringArray(name)
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/manifest"
+	"github.com/g1ntas/accio/internal/setup"
+)
+
+// errProblemsFound is returned when linted generator has problems.
+var errProblemsFound = errors.New("problems found")
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [generator]",
+	Short: "Check a generator for problems without running it",
+	Long: `Checks generator for problems without running it, and reports
+all of them at once, each with the file and line it was found at.
+
+The manifest is parsed and validated, and each blueprint is parsed
+with all Starlark scripts compiled, but not executed, and Mustache
+templates checked for syntax errors. Blueprints are checked the
+same way as in strict mode of 'accio run' command as well, so
+unknown tags and attributes, duplicate filename or template tags,
+and variable or partial tags without a name are reported. Starlark
+modules are compiled the same way as scripts of the blueprints.
+
+Generator location is accepted the same way as in 'accio run'
+command. Exits with non-zero status if any problems are found.
+Example:
+  accio lint ./generator
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		treeReader, err := urlToTreeReader(args[0])
+		if err != nil {
+			return err
+		}
+		problems := lintGenerator(treeReader)
+		if len(problems) == 0 {
+			env.log.Info("No problems found.")
+			return nil
+		}
+		printProblems(cmd.OutOrStdout(), problems)
+		return fmt.Errorf("%w: %d", errProblemsFound, len(problems))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+// lintGenerator checks manifest and all files of the generator, and
+// returns found problems prefixed with the path and line of the file.
+func lintGenerator(r generator.FileTreeReader) []string {
+	var problems []string
+	ignore := map[string]bool{".git": true, setup.ManifestFilename: true}
+	b, err := r.ReadFile(setup.ManifestFilename)
+	if err == nil {
+		var gen *manifest.Generator
+		gen, err = manifest.ReadToml(b)
+		if gen != nil {
+			for _, p := range gen.Ignore {
+				ignore[cleanPath(p)] = true
+			}
+		}
+	}
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %s", setup.ManifestFilename, err))
+	}
+	err = r.Walk(func(path string, isDir bool, err error) error {
+		path = cleanPath(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, err))
+			return nil
+		}
+		if ignore[path] {
+			if isDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if isDir || (ext != ".accio" && ext != ".star") {
+			return nil
+		}
+		b, err := r.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, err))
+			return nil
+		}
+		if ext == ".star" {
+			if err := blueprint.LintModule(filepath.ToSlash(path), b); err != nil {
+				problems = append(problems, err.Error())
+			}
+			return nil
+		}
+		for _, e := range blueprint.Lint(b) {
+			problems = append(problems, formatParseError(path, e))
+		}
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+func formatParseError(path string, e *blueprint.ParseError) string {
+	path = filepath.ToSlash(path)
+	if e.Tag == "" {
+		return fmt.Sprintf("%s:%d: %s", path, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", path, e.Line, e.Tag, e.Msg)
+}
+
+func printProblems(w io.Writer, problems []string) {
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+}
+
+// cleanPath returns path relative to the root of the generator.
+func cleanPath(p string) string {
+	return strings.TrimPrefix(filepath.Clean(p), string(filepath.Separator))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"os"
@@ -55,6 +56,10 @@ func init() {
 // In addition, it handles errors returned by inner commands by printing them.
 func main() {
 	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		if isReported(err) {
+			printErr(err)
+			os.Exit(1)
+		}
 		if cmd == nil {
 			printErr(err)
 			os.Exit(1)
@@ -65,6 +70,12 @@ func main() {
 	}
 }
 
+// isReported checks whether details of the error were already printed
+// by the command, in which case usage is irrelevant.
+func isReported(err error) bool {
+	return errors.Is(err, errChangesPending) || errors.Is(err, errProblemsFound) || errors.Is(err, errTestsFailed)
+}
+
 func printErr(e error) {
 	env.log.Info("ERROR: " + e.Error())
 }
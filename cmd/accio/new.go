@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"path/filepath"
+
+	"github.com/g1ntas/accio/internal/manifest"
+	"github.com/g1ntas/accio/internal/setup"
+)
+
+// sampleBlueprintFilename is the name of the sample blueprint written to a new generator.
+const sampleBlueprintFilename = "example.txt.accio"
+
+const sampleBlueprint = `# A sample blueprint, see docs/reference/blueprints.md for all tags.
+
+# Define a variable with Starlark code
+variable -name="greeting" <<
+    return "Hello"
+>>
+
+# Change the path of the generated file
+filename <<
+    return "example.txt"
+>>
+
+# Skip the file when the condition is true
+skipif <<
+    return False
+>>
+
+# Output content of the file with Mustache template
+template <<
+{{greeting}}, world!
+>>
+`
+
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create a new generator",
+}
+
+var newGeneratorCmd = &cobra.Command{
+	Use:   "generator [directory]",
+	Short: "Scaffold a new generator in the directory",
+	Long: `Creates a new generator in the given directory. The manifest
+'.accio.toml' is built interactively by asking for the help
+text of the generator, its prompts and ignored paths. A sample
+blueprint 'example.txt.accio' is written next to the manifest.
+The directory must not contain a manifest already.
+Example:
+  accio new generator ./my-generator
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+		manifestPath := filepath.Join(dir, setup.ManifestFilename)
+		if _, err := env.fs.Stat(manifestPath); err == nil {
+			return fmt.Errorf("generator already exists at %s", dir)
+		}
+		b, err := manifest.Scaffold(env.prompter)
+		if err != nil {
+			return err
+		}
+		if _, err = manifest.ReadToml(b); err != nil {
+			return fmt.Errorf("validating manifest: %w", err)
+		}
+		if err = env.fs.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err = env.fs.WriteFile(manifestPath, b, 0644); err != nil {
+			return fmt.Errorf("writing manifest: %w", err)
+		}
+		blueprintPath := filepath.Join(dir, sampleBlueprintFilename)
+		exists, err := env.fs.Exists(blueprintPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = env.fs.WriteFile(blueprintPath, []byte(sampleBlueprint), 0644); err != nil {
+				return fmt.Errorf("writing sample blueprint: %w", err)
+			}
+		}
+		env.log.Info("Generator created at ", dir)
+		return nil
+	},
+}
+
+func init() {
+	newCmd.AddCommand(newGeneratorCmd)
+	rootCmd.AddCommand(newCmd)
+}
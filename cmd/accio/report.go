@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/g1ntas/accio/generator"
+)
+
+// Supported formats of --output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// report is a machine-readable report of the run, printed with --output=json flag.
+type report struct {
+	Started  time.Time                   `json:"started"`
+	Duration float64                     `json:"duration_ms"`
+	Events   []reportEvent               `json:"events"`
+	Summary  map[generator.EventType]int `json:"summary"`
+	Error    string                      `json:"error,omitempty"`
+}
+
+type reportEvent struct {
+	Type    generator.EventType `json:"type"`
+	Source  string              `json:"source"`
+	Target  string              `json:"target,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	Elapsed float64             `json:"elapsed_ms"` // time since the start of the run
+}
+
+func newReport() *report {
+	return &report{
+		Started: time.Now(),
+		Events:  []reportEvent{},
+		Summary: make(map[generator.EventType]int),
+	}
+}
+
+// record adds event of the runner to the report.
+func (r *report) record(e generator.Event) {
+	ev := reportEvent{
+		Type:    e.Type,
+		Source:  e.Source,
+		Target:  e.Target,
+		Elapsed: milliseconds(time.Since(r.Started)),
+	}
+	if e.Err != nil {
+		ev.Error = e.Err.Error()
+	}
+	r.Events = append(r.Events, ev)
+	r.Summary[e.Type]++
+}
+
+// finish completes the report with the result of the run and writes it as JSON.
+func (r *report) finish(w io.Writer, err error) error {
+	r.Duration = milliseconds(time.Since(r.Started))
+	if err != nil {
+		r.Error = err.Error()
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -1,20 +1,30 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/g1ntas/accio/generator"
 	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/gitgetter"
 	"github.com/g1ntas/accio/internal/fs"
 	"github.com/g1ntas/accio/internal/logger"
 	"github.com/g1ntas/accio/internal/manifest"
+	"github.com/g1ntas/accio/internal/setup"
 )
 
-const manifestFilename = ".accio.toml"
+// lockFilename is the name of the file within working directory, which records
+// the generator, answers and generated files, when running with --lock flag.
+const lockFilename = ".accio-lock.toml"
+
+// generatedDir is the directory within working directory, where generated
+// content is saved for merging, when running with --merge flag.
+var generatedDir = filepath.Join(".accio", "generated")
 
 var runCmd = &cobra.Command{
 	Use:   "run [generator]",
@@ -63,9 +73,71 @@ Git repository URLs:
   github.com/owner/repo#refs/tags/1.0.0
   github.com/owner/repo#refs/branch/some-branch
   github.com/owner/repo#HEAD
+
+Lock file:
+  With --lock flag, the location of the generator, the resolved 
+  git commit, prompt answers, and the hash of each generated 
+  file are recorded in '.accio-lock.toml' file inside the 
+  working directory, and generated content is saved in 
+  '.accio/generated' directory, so the project can be upgraded 
+  later with 'accio upgrade' command. Credentials are removed 
+  from the git repository URL, and answers to password prompts 
+  are not recorded.
+  Example:
+  accio run github.com/owner/repo --lock
+
+Merging changes:
+  With --merge flag, generated content of each file is saved 
+  in '.accio/generated' directory inside the working directory. 
+  On subsequent runs with --merge flag, changes of the generator 
+  are merged into existing files, keeping the changes made by 
+  the user. Conflicting changes are marked with conflict markers, 
+  and the paths of conflicted files are reported. Files without 
+  previously generated content are handled as usual.
+  Example:
+  accio run ./generator --merge
+
+Previewing changes:
+  With --dry and --diff flags together, no files are written, 
+  and instead unified diffs of modified files, and lists of new 
+  and skipped files are printed. If there are pending changes, 
+  the command exits with non-zero status. See also 'accio diff'.
+  Example:
+  accio run ./generator --dry --diff
+
+Machine-readable report:
+  With --output=json flag, a report of the run is printed in 
+  JSON format, listing what happened to each file (created, 
+  overwritten, skipped-existing, skipped-by-skipif, 
+  skipped-empty-name, ignored or errored) with time elapsed since 
+  the start of the run, the number of files per event type, and 
+  the total duration. Ignored files include Starlark modules.
+  Example:
+  accio run ./generator --answers answers.toml --output json
+
+Non-interactive runs:
+  Prompt answers can be provided with --answers flag, pointing 
+  to a TOML, JSON or YAML file, and with --set flag, which takes 
+  precedence over the file. In this case, the user won't be 
+  prompted and every prompt must have a valid answer. Values 
+  of multi-choice prompts can be set as comma-separated lists.
+  Examples:
+  accio run ./generator --answers answers.toml
+  accio run ./generator --set name=John --set colors=Red,Blue
 `,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		showDiff := getBoolFlag(cmd, "diff")
+		if showDiff && !getBoolFlag(cmd, "dry") {
+			return errors.New("--diff flag can only be used together with --dry flag")
+		}
+		output := getStringFlag(cmd, "output")
+		switch {
+		case output != outputText && output != outputJSON:
+			return fmt.Errorf("unsupported output format %q, expected %s or %s", output, outputText, outputJSON)
+		case output == outputJSON && showDiff:
+			return errors.New("--diff flag can't be used with json output")
+		}
 		treeReader, gen, err := fetchGenerator(args[0])
 		if err != nil {
 			return err
@@ -75,29 +147,67 @@ Git repository URLs:
 			return err
 		}
 		env.log.Debug("working directory: ", writeDir)
-		data, err := gen.PromptAll(env.prompter)
+		gen.SetWorkingDir(writeDir)
+		data, err := collectData(cmd, gen)
 		if err != nil {
 			return err
 		}
-		parser, err := blueprint.NewParser(data, logger.NewFromLogger(env.log, "blueprint"))
+		answers := lockedAnswers(gen, data)
+		if err = gen.ComputeVariables(data); err != nil {
+			return err
+		}
+		parser, err := blueprint.NewParser(data, logger.NewFromLogger(env.log, "blueprint"), parserOptions(cmd, treeReader)...)
 		if err != nil {
 			return err
 		}
-		options := append([]generator.OptionFn{
-			generator.OnFileExists(existingFileHandler(cmd)),
-			generator.WithLogger(logger.NewFromLogger(env.log, "generator")),
-			generator.IgnorePath(".git"),
-			generator.IgnorePath(manifestFilename),
-		}, ignoredPaths(gen)...)
+		onExists := existingFileHandler(cmd)
+		var skipped []string
+		if showDiff {
+			onExists = skippedFileHandler(onExists, &skipped)
+		}
+		options := append(runnerOptions(gen, parser), generator.OnFileExists(onExists))
 		if getBoolFlag(cmd, "ignore-errors") {
 			options = append(options, generator.SkipErrors)
 		}
-		runner := generator.NewRunner(filesystem(cmd), parser, writeDir, options...)
+		if getBoolFlag(cmd, "lock") {
+			lock := &generator.Lock{Source: lockSource(args[0]), Commit: commitOf(treeReader), Answers: answers}
+			options = append(options, generator.WriteLock(lockFilename, lock), generator.SaveGenerated(generatedDir))
+		}
+		var conflicts []string
+		if getBoolFlag(cmd, "merge") {
+			options = append(options, generator.MergeChanges(generatedDir), generator.OnMergeConflict(func(path string) {
+				conflicts = append(conflicts, path)
+			}))
+		}
+		var rep *report
+		if output == outputJSON {
+			rep = newReport()
+			options = append(options, generator.OnEvent(rep.record))
+		}
+		var fs generator.Filesystem = filesystem(cmd)
+		var rec *generator.Recorder
+		if showDiff {
+			rec = generator.NewRecorder(fs)
+			rec.Ignore(filepath.Join(writeDir, lockFilename), filepath.Join(writeDir, generatedDir))
+			fs = rec
+		}
+		runner := generator.NewRunner(fs, parser, writeDir, options...)
 		env.log.Info("Running...")
 		err = runner.Run(treeReader)
+		if err == nil {
+			err = reportConflicts(conflicts)
+		}
+		if rep != nil {
+			if reportErr := rep.finish(cmd.OutOrStdout(), err); reportErr != nil {
+				return reportErr
+			}
+		}
 		if err != nil {
 			return err
 		}
+		if rec != nil {
+			return printChanges(cmd.OutOrStdout(), writeDir, rec.Changes(), skipped)
+		}
 		env.log.Info("Done.")
 		return nil
 	},
@@ -105,10 +215,17 @@ Git repository URLs:
 
 func init() {
 	runCmd.SetHelpFunc(generatorHelpFunc)
+	runCmd.Flags().String("answers", "", "Read prompt answers from a TOML, JSON or YAML file instead of prompting")
+	runCmd.Flags().Bool("diff", false, "Print changes of the dry run")
 	runCmd.Flags().Bool("dry", false, "Run without writing to filesystem")
 	runCmd.Flags().BoolP("force", "f", false, "Overwrite existing paths without asking confirmation")
 	runCmd.Flags().BoolP("help", "h", false, "Show help")
 	runCmd.Flags().BoolP("ignore-errors", "i", false, "Ignore errors for files being generated")
+	runCmd.Flags().Bool("lock", false, "Record the generator, answers and generated files in "+lockFilename)
+	runCmd.Flags().Bool("merge", false, "Merge changes into existing files with content generated by the previous run")
+	runCmd.Flags().StringP("output", "o", outputText, "Output format of the run result, either text or json")
+	runCmd.Flags().StringArray("set", []string{}, "Set prompt answer in key=value format, can be used multiple times")
+	runCmd.Flags().Bool("strict", true, "Fail on unknown tags and attributes, duplicate tags and missing names in blueprints")
 	runCmd.Flags().StringP("working-dir", "w", "", "Specify working directory")
 	rootCmd.AddCommand(runCmd)
 }
@@ -118,7 +235,7 @@ func fetchGenerator(src string) (generator.FileTreeReader, *manifest.Generator,
 	if err != nil {
 		return nil, nil, err
 	}
-	gen, err := readManifest(treeReader)
+	gen, err := setup.ReadManifest(treeReader)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -126,18 +243,6 @@ func fetchGenerator(src string) (generator.FileTreeReader, *manifest.Generator,
 	return treeReader, gen, nil
 }
 
-func readManifest(r generator.FileTreeReader) (*manifest.Generator, error) {
-	b, err := r.ReadFile(manifestFilename)
-	if err != nil {
-		return nil, fmt.Errorf("reading manifest: %w", err)
-	}
-	gen, err := manifest.ReadToml(b)
-	if err != nil {
-		return nil, fmt.Errorf("parsing manifest: %w", err)
-	}
-	return gen, nil
-}
-
 func urlToTreeReader(src string) (generator.FileTreeReader, error) {
 	info, err := env.fs.Stat(src)
 	if err == nil && info.IsDir() {
@@ -148,11 +253,60 @@ func urlToTreeReader(src string) (generator.FileTreeReader, error) {
 	return env.git.Get(src)
 }
 
-func ignoredPaths(gen *manifest.Generator) (paths []generator.OptionFn) {
-	for _, p := range gen.Ignore {
-		paths = append(paths, generator.IgnorePath(p))
+// parserOptions returns options of the blueprint parser shared by commands generating files.
+func parserOptions(cmd *cobra.Command, r generator.FileTreeReader) []blueprint.OptionFn {
+	return setup.ParserOptions(r, getBoolFlag(cmd, "strict"))
+}
+
+// runnerOptions returns options of the runner shared by commands generating files.
+func runnerOptions(gen *manifest.Generator, parser *blueprint.Parser) []generator.OptionFn {
+	return setup.RunnerOptions(gen, parser, logger.NewFromLogger(env.log, "generator"))
+}
+
+// reportConflicts logs paths of files merged with conflicts,
+// and returns an error, if there are any.
+func reportConflicts(conflicts []string) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	env.log.Info("Merge conflicts in:")
+	for _, path := range conflicts {
+		env.log.Info("  ", path)
+	}
+	return fmt.Errorf("%d file(s) merged with conflicts", len(conflicts))
+}
+
+// lockedAnswers returns answers of prompts to record in lock file.
+// Answers to password prompts are left out, so they are prompted again.
+func lockedAnswers(gen *manifest.Generator, data map[string]interface{}) map[string]interface{} {
+	answers := make(map[string]interface{})
+	for _, k := range gen.PromptKeys() {
+		if gen.IsSecret(k) {
+			continue
+		}
+		if v, ok := data[k]; ok && v != nil {
+			answers[k] = v
+		}
 	}
-	return paths
+	return answers
+}
+
+// lockSource returns location of the generator to record in lock file,
+// removing credentials from git repository URL.
+func lockSource(src string) string {
+	if info, err := env.fs.Stat(src); err == nil && info.IsDir() {
+		return src
+	}
+	return gitgetter.StripCredentials(src)
+}
+
+// commitOf returns the commit, which the generator was read from,
+// or an empty string, if the generator wasn't read from git repository.
+func commitOf(r generator.FileTreeReader) string {
+	if c, ok := r.(interface{ Commit() string }); ok {
+		return c.Commit()
+	}
+	return ""
 }
 
 // generatorHelpFunc defines run command's help behaviour.
@@ -185,22 +339,32 @@ func generatorHelpFunc(cmd *cobra.Command, args []string) {
 func filesystem(cmd *cobra.Command) afero.Afero {
 	if getBoolFlag(cmd, "dry") {
 		env.log.Debug("running in dry mode")
-		roBase := afero.NewReadOnlyFs(env.fs.Fs)
-		ufs := afero.NewCopyOnWriteFs(roBase, afero.NewMemMapFs())
-		return afero.Afero{Fs: ufs}
+		return dryFilesystem()
 	}
 	return env.fs
 }
 
+// dryFilesystem returns filesystem, which keeps all the changes in memory.
+func dryFilesystem() afero.Afero {
+	roBase := afero.NewReadOnlyFs(env.fs.Fs)
+	ufs := afero.NewCopyOnWriteFs(roBase, afero.NewMemMapFs())
+	return afero.Afero{Fs: ufs}
+}
+
 func existingFileHandler(cmd *cobra.Command) generator.OnExistsFn {
 	force := getBoolFlag(cmd, "force")
+	interactive := !isNonInteractive(cmd)
 	return func(path string) bool {
 		if force {
 			env.log.Info("Force overwriting file at ", path)
 			return true
 		}
-		fmt.Printf("File at path %q already exists\n", path)
-		overwrite, err := env.prompter.Confirm("Do you want to overwrite it?", "")
+		if !interactive {
+			env.log.Info("File at path ", path, " already exists, skipping...")
+			return false
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "File at path %q already exists\n", path)
+		overwrite, err := env.prompter.Confirm("Do you want to overwrite it?", "", false)
 		if err != nil {
 			env.log.Info("ERROR: ", err)
 			return false
@@ -227,7 +391,8 @@ func buildGeneratorHelp(gen *manifest.Generator) string {
 		return help
 	}
 	help += "\n\nPrompts:\n"
-	for name, pr := range gen.Prompts {
+	for _, name := range gen.PromptKeys() {
+		pr := gen.Prompts[name]
 		help += fmt.Sprintf("[%s]\n", name)
 		switch h := pr.Help(); {
 		case len(h) > 0:
@@ -235,6 +400,9 @@ func buildGeneratorHelp(gen *manifest.Generator) string {
 		default:
 			help += "This prompt has no description"
 		}
+		if def := pr.Default(); def != nil {
+			help += fmt.Sprintf("\nDefault: %v", def)
+		}
 		help += "\n\n"
 	}
 	return strings.TrimSpace(help)
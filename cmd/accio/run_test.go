@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/g1ntas/accio/internal/manifest"
+)
+
+// useMemFs replaces filesystem of the environment with in-memory one for the duration of the test.
+func useMemFs(t *testing.T) afero.Afero {
+	orig := env.fs
+	env.fs = afero.Afero{Fs: afero.NewMemMapFs()}
+	t.Cleanup(func() {
+		env.fs = orig
+	})
+	return env.fs
+}
+
+func TestLockedAnswers(t *testing.T) {
+	gen, err := manifest.ReadToml([]byte(`
+[prompts.name]
+type = "input"
+message = "Name:"
+
+[prompts.token]
+type = "password"
+message = "Token:"
+
+[prompts.skipped]
+type = "input"
+message = "Skipped:"
+`))
+	require.NoError(t, err)
+	data := map[string]interface{}{"name": "accio", "token": "secret", "skipped": nil, "variable": "computed"}
+	require.Equal(t, map[string]interface{}{"name": "accio"}, lockedAnswers(gen, data))
+}
+
+func TestLockSource(t *testing.T) {
+	fs := useMemFs(t)
+	require.NoError(t, fs.MkdirAll("user:pass@dir", 0755))
+	require.Equal(t, "user:pass@dir", lockSource("user:pass@dir"))
+	require.Equal(t, "github.com/owner/repo#HEAD", lockSource("user:pass@github.com/owner/repo#HEAD"))
+	require.Equal(t, "git@github.com:owner/repo", lockSource("git@github.com:owner/repo"))
+}
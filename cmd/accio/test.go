@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/logger"
+	"github.com/g1ntas/accio/internal/setup"
+)
+
+// testsDir is the directory within generator holding its test cases.
+const testsDir = "tests"
+
+// testOutputDir is the directory of in-memory filesystem, where test cases are generated.
+const testOutputDir = "/output"
+
+// errTestsFailed is returned when any test case of the generator fails.
+var errTestsFailed = errors.New("tests failed")
+
+var testCmd = &cobra.Command{
+	Use:   "test [generator]",
+	Short: "Test a generator against expected outputs",
+	Long: `Runs test cases of the generator and compares generated files
+with the expected ones. Test cases are read from the 'tests'
+directory of the generator, where each subdirectory is a single
+test case containing:
+  answers.toml - answers to prompts, can be a TOML, JSON or
+                 YAML file, same as with --answers flag of
+                 'accio run' command;
+  expected/    - directory with expected generated files.
+
+Each test case is generated in memory, and differences from the
+expected files are printed as unified diffs. Exits with non-zero
+status if any test case fails. The 'tests' directory is never
+generated by test cases, but it should be added to the ignore
+list of the manifest, so it's not generated by 'accio run'.
+
+With --update flag, expected files are replaced with generated
+ones, which requires generator to be in a local directory.
+Examples:
+  accio test ./generator
+  accio test ./generator --update
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		treeReader, err := urlToTreeReader(args[0])
+		if err != nil {
+			return err
+		}
+		update := getBoolFlag(cmd, "update")
+		if update {
+			if info, err := env.fs.Stat(args[0]); err != nil || !info.IsDir() {
+				return errors.New("--update flag requires generator in a local directory")
+			}
+		}
+		cases, err := readTestCases(treeReader)
+		if err != nil {
+			return err
+		}
+		if len(cases) == 0 {
+			return fmt.Errorf("no test cases found in %q directory of the generator", testsDir)
+		}
+		out := cmd.OutOrStdout()
+		failed := 0
+		for _, tc := range cases {
+			files, err := runTestCase(cmd, treeReader, tc)
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "--- FAIL: %s\n%s\n", tc.name, err)
+				continue
+			}
+			if update {
+				if err = writeExpected(args[0], tc, files); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "--- UPDATED: %s\n", tc.name)
+				continue
+			}
+			if diffs := compareFiles(tc.expected, files); len(diffs) > 0 {
+				failed++
+				fmt.Fprintf(out, "--- FAIL: %s\n%s", tc.name, strings.Join(diffs, ""))
+				continue
+			}
+			fmt.Fprintf(out, "--- PASS: %s\n", tc.name)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%w: %d of %d", errTestsFailed, failed, len(cases))
+		}
+		return nil
+	},
+}
+
+func init() {
+	testCmd.Flags().Bool("strict", true, "Fail on unknown tags and attributes, duplicate tags and missing names in blueprints")
+	testCmd.Flags().Bool("update", false, "Replace expected files with generated ones")
+	rootCmd.AddCommand(testCmd)
+}
+
+// testCase is a single test case of the generator.
+type testCase struct {
+	name    string
+	answers map[string]interface{}
+	// expected holds content of expected files by their path relative to the output directory.
+	expected map[string][]byte
+}
+
+// readTestCases reads test cases from tests directory of the generator, sorted by name.
+func readTestCases(r generator.FileTreeReader) ([]*testCase, error) {
+	cases := make(map[string]*testCase)
+	err := r.Walk(func(path string, isDir bool, err error) error {
+		if err != nil {
+			return err
+		}
+		parts := strings.SplitN(filepath.ToSlash(cleanPath(path)), "/", 4)
+		if parts[0] != testsDir || len(parts) < 2 {
+			return nil
+		}
+		if len(parts) == 2 {
+			if isDir {
+				cases[parts[1]] = &testCase{name: parts[1], answers: make(map[string]interface{}), expected: make(map[string][]byte)}
+			}
+			return nil
+		}
+		tc := cases[parts[1]]
+		switch {
+		case isDir || tc == nil:
+			return nil
+		case len(parts) == 3 && strings.TrimSuffix(parts[2], filepath.Ext(parts[2])) == "answers":
+			b, err := r.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if tc.answers, err = decodeAnswers(b, filepath.Ext(parts[2])); err != nil {
+				return fmt.Errorf("parsing answers of test case %q: %w", tc.name, err)
+			}
+		case len(parts) == 4 && parts[2] == "expected":
+			b, err := r.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			tc.expected[parts[3]] = b
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cases))
+	for name := range cases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]*testCase, len(names))
+	for i, name := range names {
+		list[i] = cases[name]
+	}
+	return list, nil
+}
+
+// runTestCase generates files of the test case in memory, and returns
+// their content by path relative to the output directory.
+func runTestCase(cmd *cobra.Command, r generator.FileTreeReader, tc *testCase) (map[string][]byte, error) {
+	gen, err := setup.ReadManifest(r)
+	if err != nil {
+		return nil, err
+	}
+	gen.SetWorkingDir(testOutputDir)
+	data, err := gen.AnswerAll(tc.answers)
+	if err != nil {
+		return nil, err
+	}
+	if err = gen.ComputeVariables(data); err != nil {
+		return nil, err
+	}
+	parser, err := blueprint.NewParser(data, logger.NewFromLogger(env.log, "blueprint"), parserOptions(cmd, r)...)
+	if err != nil {
+		return nil, err
+	}
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	options := append(runnerOptions(gen, parser), generator.IgnorePath(testsDir))
+	if err = generator.NewRunner(fs, parser, testOutputDir, options...).Run(r); err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	err = fs.Walk(testOutputDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == testOutputDir {
+			return nil
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[relPath(testOutputDir, path)] = b
+		return nil
+	})
+	return files, err
+}
+
+// compareFiles returns differences between expected and generated files.
+func compareFiles(expected, generated map[string][]byte) []string {
+	paths := make([]string, 0, len(expected))
+	for p := range expected {
+		paths = append(paths, p)
+	}
+	for p := range generated {
+		if _, ok := expected[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var diffs []string
+	for _, p := range paths {
+		want, isExpected := expected[p]
+		got, isGenerated := generated[p]
+		switch {
+		case !isGenerated:
+			diffs = append(diffs, fmt.Sprintf("missing file %s\n", p))
+		case !isExpected:
+			diffs = append(diffs, fmt.Sprintf("unexpected file %s\n", p))
+		default:
+			if d := generator.UnifiedDiff(p, want, got); d != "" {
+				diffs = append(diffs, d)
+			}
+		}
+	}
+	return diffs
+}
+
+// writeExpected replaces expected files of the test case within the generator at src.
+func writeExpected(src string, tc *testCase, files map[string][]byte) error {
+	dir := filepath.Join(src, testsDir, tc.name, "expected")
+	if err := env.fs.RemoveAll(dir); err != nil {
+		return fmt.Errorf("updating test case %q: %w", tc.name, err)
+	}
+	for p, b := range files {
+		path := filepath.Join(dir, filepath.FromSlash(p))
+		if err := env.fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("updating test case %q: %w", tc.name, err)
+		}
+		if err := env.fs.WriteFile(path, b, 0644); err != nil {
+			return fmt.Errorf("updating test case %q: %w", tc.name, err)
+		}
+	}
+	return nil
+}
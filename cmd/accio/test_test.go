@@ -0,0 +1,127 @@
+package main
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/g1ntas/accio/internal/fs"
+	"github.com/g1ntas/accio/internal/setup"
+)
+
+// memTree returns file tree of the generator with given files in memory.
+func memTree(t *testing.T, files map[string]string) fs.AferoFileTreeReader {
+	base := afero.NewMemMapFs()
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(base, "/generator/"+path, []byte(content), 0644))
+	}
+	return fs.NewAferoFileTreeReader(base, "/generator")
+}
+
+const testCaseManifest = `
+ignore = ["tests"]
+
+[prompts.name]
+type = "input"
+message = "Name:"
+`
+
+func TestReadTestCases(t *testing.T) {
+	tree := memTree(t, map[string]string{
+		setup.ManifestFilename:           testCaseManifest,
+		"tests/README.md":                "not a test case",
+		"tests/.gitkeep":                 "",
+		"tests/b/answers.toml":           `name = "b"`,
+		"tests/b/expected/dir/file.txt":  "b",
+		"tests/a/answers.json":           `{"name": "a"}`,
+		"tests/a/expected/file.txt":      "a",
+		"tests/a/notes.txt":              "ignored",
+		"tests/empty/expected/.keep":     "",
+		"other/tests/c/answers.toml":     `name = "c"`,
+		"other/tests/c/expected/a.txt":   "c",
+		"tests/b/expected/nested/b.txt":  "nested",
+		"tests/a/expected/file.txt.orig": "a",
+	})
+	cases, err := readTestCases(tree)
+	require.NoError(t, err)
+	require.Equal(t, []*testCase{
+		{
+			name:     "a",
+			answers:  map[string]interface{}{"name": "a"},
+			expected: map[string][]byte{"file.txt": []byte("a"), "file.txt.orig": []byte("a")},
+		},
+		{
+			name:     "b",
+			answers:  map[string]interface{}{"name": "b"},
+			expected: map[string][]byte{"dir/file.txt": []byte("b"), "nested/b.txt": []byte("nested")},
+		},
+		{
+			name:     "empty",
+			answers:  map[string]interface{}{},
+			expected: map[string][]byte{".keep": {}},
+		},
+	}, cases)
+}
+
+func TestReadTestCasesInvalidAnswers(t *testing.T) {
+	tree := memTree(t, map[string]string{"tests/a/answers.toml": "name ="})
+	_, err := readTestCases(tree)
+	require.Error(t, err)
+}
+
+var compareFilesTests = []struct {
+	name      string
+	expected  map[string][]byte
+	generated map[string][]byte
+	diffs     []string
+}{
+	{"equal", map[string][]byte{"a.txt": []byte("a\n")}, map[string][]byte{"a.txt": []byte("a\n")}, nil},
+	{"missing", map[string][]byte{"a.txt": []byte("a\n")}, map[string][]byte{}, []string{"missing file a.txt\n"}},
+	{"unexpected", map[string][]byte{}, map[string][]byte{"a.txt": []byte("a\n")}, []string{"unexpected file a.txt\n"}},
+	{
+		"modified",
+		map[string][]byte{"a.txt": []byte("a\n")},
+		map[string][]byte{"a.txt": []byte("b\n")},
+		[]string{"--- a/a.txt\n+++ b/a.txt\n@@ -1,1 +1,1 @@\n-a\n+b\n"},
+	},
+	{
+		"sorted by path",
+		map[string][]byte{"b.txt": []byte("b\n"), "c.txt": []byte("c\n")},
+		map[string][]byte{"a.txt": []byte("a\n"), "c.txt": []byte("c\n")},
+		[]string{"unexpected file a.txt\n", "missing file b.txt\n"},
+	},
+}
+
+func TestCompareFiles(t *testing.T) {
+	for _, test := range compareFilesTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.diffs, compareFiles(test.expected, test.generated))
+		})
+	}
+}
+
+func TestRunTestCase(t *testing.T) {
+	tree := memTree(t, map[string]string{
+		setup.ManifestFilename:      testCaseManifest,
+		"static.txt":                "static",
+		"dir/hello.txt.accio":       "template <<Hello, {{name}}!>>",
+		"tests/a/answers.toml":      `name = "a"`,
+		"tests/a/expected/file.txt": "a",
+	})
+	files, err := runTestCase(testCmd, tree, &testCase{name: "a", answers: map[string]interface{}{"name": "Accio"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		"static.txt":    []byte("static"),
+		"dir/hello.txt": []byte("Hello, Accio!"),
+	}, files)
+
+	_, err = runTestCase(testCmd, tree, &testCase{name: "b", answers: map[string]interface{}{}})
+	require.Error(t, err)
+}
+
+func TestRunTestCaseWithoutFiles(t *testing.T) {
+	tree := memTree(t, map[string]string{setup.ManifestFilename: testCaseManifest})
+	files, err := runTestCase(testCmd, tree, &testCase{name: "a", answers: map[string]interface{}{"name": "a"}})
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
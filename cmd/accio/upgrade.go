@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/logger"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Re-apply a newer version of the generator to the project",
+	Long: `Re-runs the generator recorded in '.accio-lock.toml' file of the
+working directory, which is written when running a generator
+with --lock flag, with the recorded prompt answers. The user is
+prompted only for prompts added in the newer version of the
+generator, and for password prompts, which aren't recorded.
+
+Files not modified since the previous run are overwritten.
+Changes of the generator are merged into modified files, using
+the content saved in '.accio/generated' directory as a base,
+and conflicting changes are marked with conflict markers.
+Modified files without the saved content are skipped. All
+modified files are reported.
+
+By default, the generator is read from the recorded location.
+With --to flag, a different git reference of the same git
+repository can be used, which must be a valid full reference.
+Examples:
+  accio upgrade
+  accio upgrade --to refs/tags/2.0.0
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		writeDir, err := workingDir(cmd)
+		if err != nil {
+			return err
+		}
+		env.log.Debug("working directory: ", writeDir)
+		lock, err := readLock(writeDir)
+		if err != nil {
+			return err
+		}
+		source, err := sourceAtRef(lock.Source, getStringFlag(cmd, "to"))
+		if err != nil {
+			return err
+		}
+		treeReader, gen, err := fetchGenerator(source)
+		if err != nil {
+			return err
+		}
+		gen.SetWorkingDir(writeDir)
+		data, err := gen.PromptMissing(env.prompter, lock.Answers)
+		if err != nil {
+			return err
+		}
+		answers := lockedAnswers(gen, data)
+		if err = gen.ComputeVariables(data); err != nil {
+			return err
+		}
+		parser, err := blueprint.NewParser(data, logger.NewFromLogger(env.log, "blueprint"), parserOptions(cmd, treeReader)...)
+		if err != nil {
+			return err
+		}
+		modified := modifiedFiles(lock, writeDir)
+		var conflicts []string
+		newLock := &generator.Lock{
+			Source:  lockSource(source),
+			Commit:  commitOf(treeReader),
+			Answers: answers,
+			Files:   append([]generator.LockedFile(nil), lock.Files...),
+		}
+		options := append(runnerOptions(gen, parser),
+			generator.MergeChanges(generatedDir),
+			generator.WriteLock(lockFilename, newLock),
+			generator.OnFileExists(upgradeHandler(lock, writeDir, modified)),
+			generator.OnMergeConflict(func(path string) {
+				conflicts = append(conflicts, path)
+			}),
+		)
+		runner := generator.NewRunner(filesystem(cmd), parser, writeDir, options...)
+		env.log.Info("Upgrading...")
+		err = runner.Run(treeReader)
+		if err != nil {
+			return err
+		}
+		if len(modified) > 0 {
+			env.log.Info("Files modified since the previous run:")
+			for _, f := range lock.Files {
+				if path := filepath.Join(writeDir, filepath.FromSlash(f.Path)); modified[path] {
+					env.log.Info("  ", path)
+				}
+			}
+		}
+		if err = reportConflicts(conflicts); err != nil {
+			return err
+		}
+		env.log.Info("Done.")
+		return nil
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().Bool("dry", false, "Run without writing to filesystem")
+	upgradeCmd.Flags().Bool("strict", true, "Fail on unknown tags and attributes, duplicate tags and missing names in blueprints")
+	upgradeCmd.Flags().String("to", "", "Git reference of the generator to upgrade to")
+	upgradeCmd.Flags().StringP("working-dir", "w", "", "Specify working directory")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func readLock(dir string) (*generator.Lock, error) {
+	b, err := env.fs.ReadFile(filepath.Join(dir, lockFilename))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found, generator must be run with --lock flag first", lockFilename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lock file: %w", err)
+	}
+	lock, err := generator.ReadLock(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lock file: %w", err)
+	}
+	return lock, nil
+}
+
+// sourceAtRef replaces git reference in the URL of the generator with
+// given reference. If reference is empty, source is returned unchanged.
+func sourceAtRef(source, ref string) (string, error) {
+	if ref == "" {
+		return source, nil
+	}
+	if info, err := env.fs.Stat(source); err == nil && info.IsDir() {
+		return "", fmt.Errorf("generator at %s is a local directory, git reference can't be changed", source)
+	}
+	if i := strings.LastIndex(source, "#"); i >= 0 {
+		source = source[:i]
+	}
+	return source + "#" + ref, nil
+}
+
+// upgradeHandler overwrites existing files, which were generated by the
+// previous run and weren't modified since, and skips all other files.
+func upgradeHandler(lock *generator.Lock, dir string, modified map[string]bool) generator.OnExistsFn {
+	return func(path string) bool {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return false
+		}
+		if _, ok := lock.File(filepath.ToSlash(rel)); ok && !modified[path] {
+			return true
+		}
+		env.log.Info("File at path ", path, " already exists, skipping...")
+		return false
+	}
+}
+
+// modifiedFiles returns paths of existing files, which content
+// changed since they were recorded in the lock.
+func modifiedFiles(lock *generator.Lock, dir string) map[string]bool {
+	modified := make(map[string]bool)
+	for _, f := range lock.Files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		b, err := env.fs.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if generator.HashContent(b) != f.Hash {
+			modified[path] = true
+		}
+	}
+	return modified
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+
+	"github.com/g1ntas/accio/generator"
+)
+
+var sourceAtRefTests = []struct {
+	name     string
+	source   string
+	ref      string
+	expected string
+	ok       bool
+}{
+	{"empty reference", "github.com/owner/repo#refs/tags/1.0.0", "", "github.com/owner/repo#refs/tags/1.0.0", true},
+	{"add reference", "github.com/owner/repo", "refs/tags/2.0.0", "github.com/owner/repo#refs/tags/2.0.0", true},
+	{"replace reference", "github.com/owner/repo#refs/tags/1.0.0", "refs/tags/2.0.0", "github.com/owner/repo#refs/tags/2.0.0", true},
+	{"local directory", "generator", "refs/tags/2.0.0", "", false},
+}
+
+func TestSourceAtRef(t *testing.T) {
+	fs := useMemFs(t)
+	require.NoError(t, fs.MkdirAll("generator", 0755))
+	for _, test := range sourceAtRefTests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := sourceAtRef(test.source, test.ref)
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, source)
+		})
+	}
+}
+
+func testLock() *generator.Lock {
+	return &generator.Lock{Files: []generator.LockedFile{
+		{Path: "unchanged.txt", Hash: generator.HashContent([]byte("a"))},
+		{Path: "dir/modified.txt", Hash: generator.HashContent([]byte("b"))},
+		{Path: "removed.txt", Hash: generator.HashContent([]byte("c"))},
+	}}
+}
+
+func TestModifiedFiles(t *testing.T) {
+	fs := useMemFs(t)
+	dir := filepath.FromSlash("/project")
+	require.NoError(t, fs.WriteFile(filepath.Join(dir, "unchanged.txt"), []byte("a"), 0644))
+	require.NoError(t, fs.WriteFile(filepath.Join(dir, "dir", "modified.txt"), []byte("changed"), 0644))
+	require.Equal(t, map[string]bool{filepath.Join(dir, "dir", "modified.txt"): true}, modifiedFiles(testLock(), dir))
+}
+
+func TestUpgradeHandler(t *testing.T) {
+	dir := filepath.FromSlash("/project")
+	modified := map[string]bool{filepath.Join(dir, "dir", "modified.txt"): true}
+	overwrite := upgradeHandler(testLock(), dir, modified)
+	require.True(t, overwrite(filepath.Join(dir, "unchanged.txt")))
+	require.False(t, overwrite(filepath.Join(dir, "dir", "modified.txt")))
+	require.False(t, overwrite(filepath.Join(dir, "untracked.txt")))
+}
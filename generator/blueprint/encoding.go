@@ -0,0 +1,259 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"gopkg.in/yaml.v3"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// codec encodes go values into a structured data format and decodes them back.
+type codec struct {
+	// encode encodes value, indenting nested values by given number of spaces.
+	encode func(v interface{}, indent int) ([]byte, error)
+	decode func(b []byte) (interface{}, error)
+}
+
+var codecs = map[string]codec{
+	"json": {encodeJSON, decodeJSON},
+	"yaml": {encodeYAML, decodeYAML},
+	"toml": {encodeTOML, decodeTOML},
+}
+
+// codecModules returns starlark modules with encode and decode functions for each codec.
+func codecModules() starlark.StringDict {
+	modules := make(starlark.StringDict, len(codecs))
+	for name, c := range codecs {
+		modules[name] = &starlarkstruct.Module{
+			Name: name,
+			Members: starlark.StringDict{
+				"encode": starlark.NewBuiltin(name+".encode", encodeBuiltin(c)),
+				"decode": starlark.NewBuiltin(name+".decode", decodeBuiltin(c)),
+			},
+		}
+	}
+	return modules
+}
+
+func encodeBuiltin(c codec) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var val starlark.Value
+		var indent int
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "value", &val, "indent?", &indent); err != nil {
+			return nil, err
+		}
+		if indent < 0 {
+			return nil, fmt.Errorf("%s: indent must not be negative", fn.Name())
+		}
+		goval, err := encodableValue(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		b, err := c.encode(goval, indent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		return starlark.String(b), nil
+	}
+}
+
+func decodeBuiltin(c codec) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "s", &s); err != nil {
+			return nil, err
+		}
+		v, err := decodeValue(c, []byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		return v, nil
+	}
+}
+
+// encodableValue translates starlark value into go value for encoding.
+// Unlike parseValue, None is translated into nil, so it's encoded as null,
+// and integers are not limited to 32 bits.
+func encodableValue(v starlark.Value) (interface{}, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Int:
+		if i, ok := val.Int64(); ok {
+			return i, nil
+		}
+		return val.BigInt(), nil
+	case starlark.Tuple, *starlark.List:
+		list := make([]interface{}, val.(starlark.Indexable).Len())
+		var err error
+		for i := range list {
+			list[i], err = encodableValue(val.(starlark.Indexable).Index(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *starlark.Dict:
+		dict := make(map[string]interface{}, val.Len())
+		for _, k := range val.Keys() {
+			v, _, err := val.Get(k)
+			if err != nil {
+				return nil, err
+			}
+			key, err := parseDictKey(k)
+			if err != nil {
+				return nil, err
+			}
+			dict[key], err = encodableValue(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return parseValue(v)
+}
+
+// decodeValue decodes data with given codec into starlark value.
+func decodeValue(c codec, b []byte) (starlark.Value, error) {
+	goval, err := c.decode(b)
+	if err != nil {
+		return nil, err
+	}
+	goval, err = normalizeDecoded(goval)
+	if err != nil {
+		return nil, err
+	}
+	return newValue(goval)
+}
+
+// encodeJSON encodes value into JSON with sorted keys and without escaping
+// HTML characters. If indent is 0, then compact JSON is returned.
+func encodeJSON(v interface{}, indent int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", strings.Repeat(" ", indent))
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// encodeYAML encodes value into YAML with sorted keys. If indent is 0,
+// then nested values are indented by 2 spaces.
+func encodeYAML(v interface{}, indent int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	if indent == 0 {
+		indent = 2
+	}
+	enc.SetIndent(indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeYAML(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// encodeTOML encodes value, which must be a dictionary, into TOML with sorted keys.
+func encodeTOML(v interface{}, indent int) ([]byte, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("expected a dict, got %T", v)
+	}
+	buf := new(bytes.Buffer)
+	enc := toml.NewEncoder(buf)
+	enc.Indent = strings.Repeat(" ", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeTOML(b []byte) (interface{}, error) {
+	var v map[string]interface{}
+	if _, err := toml.Decode(string(b), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// normalizeDecoded converts values decoded from structured data into
+// data types supported by newValue.
+func normalizeDecoded(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		if i, ok := new(big.Int).SetString(val.String(), 10); ok {
+			return i, nil
+		}
+		return val.Float64()
+	case int:
+		return int64(val), nil
+	case uint64:
+		return float64(val), nil
+	case time.Time:
+		return val.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, el := range val {
+			n, err := normalizeDecoded(el)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = n
+		}
+		return list, nil
+	case []map[string]interface{}:
+		list := make([]interface{}, len(val))
+		for i, el := range val {
+			list[i] = el
+		}
+		return normalizeDecoded(list)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, el := range val {
+			n, err := normalizeDecoded(el)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = n
+		}
+		return m, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, el := range val {
+			m[strings.TrimSpace(fmt.Sprint(k))] = el
+		}
+		return normalizeDecoded(m)
+	}
+	return v, nil
+}
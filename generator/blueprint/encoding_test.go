@@ -0,0 +1,49 @@
+package blueprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var encodingTests = []struct {
+	name     string
+	script   string
+	expected interface{}
+	ok       bool
+}{
+	{"json encode", `json.encode({"b": [1, 2.5, True, None], "a": "<&>"})`, `{"a":"<&>","b":[1,2.5,true,null]}`, noError},
+	{"json encode with indent", `json.encode({"a": 1}, indent=2)`, "{\n  \"a\": 1\n}", noError},
+	{"json encode negative indent", `json.encode({"a": 1}, indent=-1)`, nil, hasError},
+	{"json encode large integers", `json.encode([2147483648, -9223372036854775808, 1 << 70])`, `[2147483648,-9223372036854775808,1180591620717411303424]`, noError},
+	{"json decode", `json.decode('{"a": [1, 2.5, "x", true], "b": {"c": 1}}')`, data{"a": []interface{}{1, 2.5, "x", true}, "b": data{"c": 1}}, noError},
+	{"json decode null", `json.decode('{"a": null, "b": [null]}') == {"a": None, "b": [None]}`, true, noError},
+	{"json decode invalid", `json.decode('{')`, nil, hasError},
+	{"yaml encode", `yaml.encode({"b": [1, 2], "a": {"c": "d"}})`, "a:\n  c: d\nb:\n  - 1\n  - 2\n", noError},
+	{"yaml encode null and large integer", `yaml.encode({"a": None, "b": 4294967296})`, "a: null\nb: 4294967296\n", noError},
+	{"yaml decode", `yaml.decode('a: 1\nb: [x, 2.5]\n1: true\n')`, data{"a": 1, "b": []interface{}{"x", 2.5}, "1": true}, noError},
+	{"yaml decode invalid", `yaml.decode('a: [')`, nil, hasError},
+	{"toml encode", `toml.encode({"b": {"c": 1}, "a": "x"})`, "a = \"x\"\n\n[b]\nc = 1\n", noError},
+	{"toml encode with indent", `toml.encode({"b": {"c": 1}}, indent=2)`, "[b]\n  c = 1\n", noError},
+	{"toml encode non-dict", `toml.encode([1])`, nil, hasError},
+	{"toml decode", `toml.decode('a = 1\n[[b]]\nc = "d"\n')`, data{"a": 1, "b": []interface{}{data{"c": "d"}}}, noError},
+	{"json round-trip", `json.decode(json.encode({"a": [1, "b"]}))`, data{"a": []interface{}{1, "b"}}, noError},
+	{"json round-trip null", `json.decode(json.encode([None, 1])) == [None, 1]`, true, noError},
+	{"json round-trip large integer", `json.decode(json.encode(1 << 70)) == 1 << 70`, true, noError},
+	{"yaml round-trip", `yaml.decode(yaml.encode({"a": [1, "b"]}))`, data{"a": []interface{}{1, "b"}}, noError},
+	{"toml round-trip", `toml.decode(toml.encode({"a": [1, 2]}))`, data{"a": []interface{}{1, 2}}, noError},
+	{"toml round-trip large integer", `toml.decode(toml.encode({"a": 4294967296})) == {"a": 4294967296}`, true, noError},
+}
+
+func TestEncoding(t *testing.T) {
+	for _, test := range encodingTests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := Eval(test.script, data{})
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, val)
+		})
+	}
+}
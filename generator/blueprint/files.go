@@ -0,0 +1,71 @@
+package blueprint
+
+import (
+	"fmt"
+	"go.starlark.net/starlark"
+	"path"
+)
+
+// localLoader is the key of thread-local module loader,
+// which is used by builtins to read files.
+const localLoader = "loader"
+
+// dataCodecs maps extensions of data files to codecs used to decode them.
+var dataCodecs = map[string]string{
+	".json": "json",
+	".toml": "toml",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+// readFile reads file from the file tree of the generator, resolving its
+// name same as module names are resolved in load statements, i.e. relative
+// names are resolved against directory of the module calling the builtin.
+func readFile(thread *starlark.Thread, name string) ([]byte, error) {
+	l, ok := thread.Local(localLoader).(*moduleLoader)
+	if !ok {
+		return nil, fmt.Errorf("reading files is not supported")
+	}
+	// frame at depth 0 is the builtin itself, so its caller is at depth 1
+	var dir string
+	if filename := thread.CallFrame(1).Pos.Filename(); filename != "" {
+		dir = path.Dir(filename)
+	}
+	p, err := resolvePath("file", dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return l.fr.ReadFile(p)
+}
+
+func builtinReadFile(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &name); err != nil {
+		return nil, err
+	}
+	b, err := readFile(thread, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return starlark.String(b), nil
+}
+
+func builtinLoadData(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "path", &name); err != nil {
+		return nil, err
+	}
+	format, ok := dataCodecs[path.Ext(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported format of file %q, expected .json, .toml, .yaml or .yml", fn.Name(), name)
+	}
+	b, err := readFile(thread, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	v, err := decodeValue(codecs[format], b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding %s: %w", fn.Name(), name, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,77 @@
+package blueprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var dataFiles = map[string]string{
+	"LICENSE":               "MIT License",
+	"data/versions.json":    `{"go": "1.15", "deps": [{"name": "toml", "version": 3}]}`,
+	"data/versions.toml":    "go = \"1.15\"\n[[deps]]\nname = \"toml\"\nversion = 3\n",
+	"data/versions.yaml":    "go: '1.15'\ndeps:\n  - name: toml\n    version: 3\n",
+	"data/invalid.json":     `{`,
+	"data/versions.txt":     "go 1.15",
+	"lib/license.star":      "def license():\n    return read_file('../LICENSE')\n",
+	"lib/license_root.star": "def license():\n    return read_file('//LICENSE')\n",
+	"lib/config.star":       "config = load_data('../data/versions.json')\nlicense = read_file('//LICENSE')\n",
+}
+
+var versions = data{"go": "1.15", "deps": []interface{}{data{"name": "toml", "version": 3}}}
+
+var fileTests = []struct {
+	name     string
+	script   string
+	expected interface{}
+	ok       bool
+}{
+	{"read file", `read_file("LICENSE")`, "MIT License", noError},
+	{"read file from root", `read_file("//data/versions.txt")`, "go 1.15", noError},
+	{"read missing file", `read_file("missing")`, nil, hasError},
+	{"read file outside generator", `read_file("../LICENSE")`, nil, hasError},
+	{"read file with absolute path", `read_file("/etc/passwd")`, nil, hasError},
+	{"read file relative to module", `license()`, "MIT License", noError},
+	{"load json", `load_data("data/versions.json")`, versions, noError},
+	{"load toml", `load_data("data/versions.toml")`, versions, noError},
+	{"load yaml", `load_data("data/versions.yaml")`, versions, noError},
+	{"load invalid data", `load_data("data/invalid.json")`, nil, hasError},
+	{"load unsupported format", `load_data("data/versions.txt")`, nil, hasError},
+	{"load data outside generator", `load_data("../data/versions.json")`, nil, hasError},
+}
+
+func TestReadFiles(t *testing.T) {
+	for _, test := range fileTests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, err := newContext(data{})
+			require.NoError(t, err)
+			ctx.loader = newModuleLoader(&fileReaderMock{files: dataFiles})
+			script := "\tload('//lib/license.star', 'license')\n" + wrapInlineScript(test.script)
+			val, err := execute(script, &ctx)
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			goval, err := parseValue(val)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, goval)
+		})
+	}
+}
+
+func TestReadFilesAtModuleTopLevel(t *testing.T) {
+	ctx, err := newContext(data{})
+	require.NoError(t, err)
+	ctx.loader = newModuleLoader(&fileReaderMock{files: dataFiles})
+	script := "\tload('//lib/config.star', 'config', 'license')\n" + wrapInlineScript(`[config, license]`)
+	val, err := execute(script, &ctx)
+	require.NoError(t, err)
+	goval, err := parseValue(val)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{versions, "MIT License"}, goval)
+}
+
+func TestReadFileNotSupported(t *testing.T) {
+	_, err := Eval(`read_file("LICENSE")`, data{})
+	require.Error(t, err)
+}
@@ -6,11 +6,67 @@ import (
 	"time"
 )
 
+// stringFuncs holds functions converting strings, which are available both as
+// starlark builtins and as precomputed variants of string variables in templates.
+var stringFuncs = []struct {
+	builtin, variant string
+	fn               func(string) string
+}{
+	{"snake_case", "snake", snakeCase},
+	{"camel_case", "camel", camelCase},
+	{"pascal_case", "pascal", pascalCase},
+	{"kebab_case", "kebab", kebabCase},
+	{"screaming_snake_case", "screaming_snake", screamingSnakeCase},
+	{"title", "title", titleCase},
+	{"pluralize", "plural", pluralize},
+	{"singularize", "singular", singularize},
+	{"go_identifier", "go_identifier", goIdentifier},
+}
+
 func predeclaredFuncs() starlark.StringDict {
-	return starlark.StringDict{
-		"strftime": starlark.NewBuiltin("strftime", builtinStrftime),
-		"time":     starlark.NewBuiltin("time", builtinTime),
+	funcs := starlark.StringDict{
+		"strftime":  starlark.NewBuiltin("strftime", builtinStrftime),
+		"time":      starlark.NewBuiltin("time", builtinTime),
+		"read_file": starlark.NewBuiltin("read_file", builtinReadFile),
+		"load_data": starlark.NewBuiltin("load_data", builtinLoadData),
+	}
+	for _, f := range stringFuncs {
+		funcs[f.builtin] = newStringBuiltin(f.builtin, f.fn)
+	}
+	for name, m := range codecModules() {
+		funcs[name] = m
+	}
+	return funcs
+}
+
+// newStringBuiltin creates a builtin, which accepts a single string and
+// returns it converted with given function.
+func newStringBuiltin(name string, fn func(string) string) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var s string
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &s); err != nil {
+			return nil, err
+		}
+		return starlark.String(fn(s)), nil
+	})
+}
+
+// stringVariants returns variants of each string variable converted with
+// string functions, e.g. variants["name"]["snake"].
+func stringVariants(vars map[string]interface{}) map[string]interface{} {
+	variants := make(map[string]interface{})
+	for k, v := range vars {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		m := make(map[string]interface{}, len(stringFuncs))
+		for _, f := range stringFuncs {
+			m[f.variant] = f.fn(s)
+		}
+		variants[k] = m
 	}
+	return variants
 }
 
 func builtinStrftime(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
@@ -47,3 +47,89 @@ func TestTime(t *testing.T) {
 	require.Equal(t, evalTime, realTime)
 
 }
+
+var stringFuncTests = []struct {
+	input                                                       string
+	snake, camel, pascal, kebab, screaming, title, goIdentifier string
+}{
+	{"hello world", "hello_world", "helloWorld", "HelloWorld", "hello-world", "HELLO_WORLD", "Hello World", "hello_world"},
+	{"fooBarBaz", "foo_bar_baz", "fooBarBaz", "FooBarBaz", "foo-bar-baz", "FOO_BAR_BAZ", "Foo Bar Baz", "fooBarBaz"},
+	{"HTTPServer", "http_server", "httpServer", "HttpServer", "http-server", "HTTP_SERVER", "Http Server", "HTTPServer"},
+	{"user_id2-name", "user_id2_name", "userId2Name", "UserId2Name", "user-id2-name", "USER_ID2_NAME", "User Id2 Name", "user_id2_name"},
+	{"  über straße ", "über_straße", "überStraße", "ÜberStraße", "über-straße", "ÜBER_STRAßE", "Über Straße", "über_straße"},
+	{"ÄpfelUndBirnen", "äpfel_und_birnen", "äpfelUndBirnen", "ÄpfelUndBirnen", "äpfel-und-birnen", "ÄPFEL_UND_BIRNEN", "Äpfel Und Birnen", "ÄpfelUndBirnen"},
+	{"1st-place", "1st_place", "1stPlace", "1stPlace", "1st-place", "1ST_PLACE", "1st Place", "_1st_place"},
+	{"type", "type", "type", "Type", "type", "TYPE", "Type", "type_"},
+	{"", "", "", "", "", "", "", "_"},
+}
+
+func TestStringCases(t *testing.T) {
+	for _, test := range stringFuncTests {
+		t.Run(test.input, func(t *testing.T) {
+			require.Equal(t, test.snake, snakeCase(test.input))
+			require.Equal(t, test.camel, camelCase(test.input))
+			require.Equal(t, test.pascal, pascalCase(test.input))
+			require.Equal(t, test.kebab, kebabCase(test.input))
+			require.Equal(t, test.screaming, screamingSnakeCase(test.input))
+			require.Equal(t, test.title, titleCase(test.input))
+			require.Equal(t, test.goIdentifier, goIdentifier(test.input))
+		})
+	}
+}
+
+var inflectionTests = []struct {
+	singular, plural string
+}{
+	{"user", "users"},
+	{"User", "Users"},
+	{"USER", "USERS"},
+	{"userAccount", "userAccounts"},
+	{"category", "categories"},
+	{"key", "keys"},
+	{"box", "boxes"},
+	{"match", "matches"},
+	{"class", "classes"},
+	{"status", "statuses"},
+	{"knife", "knives"},
+	{"wolf", "wolves"},
+	{"analysis", "analyses"},
+	{"datum", "data"},
+	{"matrix", "matrices"},
+	{"index", "indices"},
+	{"quiz", "quizzes"},
+	{"potato", "potatoes"},
+	{"person", "people"},
+	{"Person", "People"},
+	{"salesPerson", "salesPeople"},
+	{"child", "children"},
+	{"woman", "women"},
+	{"sheep", "sheep"},
+	{"news", "news"},
+	{"café", "cafés"},
+}
+
+func TestInflection(t *testing.T) {
+	for _, test := range inflectionTests {
+		t.Run(test.singular, func(t *testing.T) {
+			require.Equal(t, test.plural, pluralize(test.singular))
+			require.Equal(t, test.singular, singularize(test.plural))
+		})
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	script := wrapInlineScript(`[snake_case("fooBar"), camel_case("foo_bar"), pascal_case("foo bar"), kebab_case("FooBar"), ` +
+		`screaming_snake_case("fooBar"), title("foo_bar"), pluralize("box"), singularize("boxes"), go_identifier("go-utils")]`)
+
+	val, err := execute(script, &mockCtx)
+	require.NoError(t, err)
+
+	list, err := parseValue(val)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"foo_bar", "fooBar", "FooBar", "foo-bar", "FOO_BAR", "Foo Bar", "boxes", "box", "go_utils"}, list)
+}
+
+func TestStringBuiltinInvalidArgument(t *testing.T) {
+	_, err := execute(wrapInlineScript(`snake_case(1)`), &mockCtx)
+	require.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package blueprint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cbroglie/mustache"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/g1ntas/accio/markup"
+)
+
+// Lint checks blueprint for problems without executing it, and returns
+// all of them at once. Scripts are only compiled, so errors occurring
+// at run time, like wrong types of values, aren't detected.
+func Lint(b []byte) []*ParseError {
+	mp, err := markup.Parse(string(b), "", "")
+	if err != nil {
+		var e *markup.Error
+		if errors.As(err, &e) {
+			return []*ParseError{{Msg: e.Msg, Line: e.Line}}
+		}
+		return []*ParseError{{Msg: err.Error()}}
+	}
+	errs := checkStrict(mp.Tags)
+	for _, tag := range mp.Tags {
+		for _, err := range lintTag(tag) {
+			errs = append(errs, toParseError(tag, err))
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	return errs
+}
+
+// LintModule checks starlark module for syntax errors and undefined names without executing it.
+func LintModule(name string, b []byte) error {
+	f, err := syntax.Parse(name, b, 0)
+	if err != nil {
+		return err
+	}
+	_, err = starlark.FileProgram(f, predeclaredFuncs().Has)
+	return err
+}
+
+// lintTag checks scripts, templates and attributes of a single tag.
+// Unknown tags and attributes are checked by checkStrict.
+func lintTag(tag *markup.TagNode) []error {
+	var errs []error
+	switch tag.Name {
+	case tagVariable, tagFilename, tagSkip, tagForeach:
+		errs = append(errs, compileScript(tag))
+	case tagPartial, tagTemplate:
+		errs = append(errs, parseTemplate(tag))
+	case tagInject:
+		errs = append(errs, lintInject(tag), parseTemplate(tag))
+	}
+	var found []error
+	for _, err := range errs {
+		if err != nil {
+			found = append(found, err)
+		}
+	}
+	return found
+}
+
+// compileScript compiles starlark script in the body of the tag.
+func compileScript(tag *markup.TagNode) error {
+	if hasEmptyBody(tag) {
+		return nil
+	}
+	f, err := syntax.Parse("", wrapScript(parseScriptBody(tag)), 0)
+	if err != nil {
+		return err
+	}
+	hoistLoads(f)
+	predeclared := predeclaredFuncs()
+	predeclared["vars"] = starlark.None
+	_, err = starlark.FileProgram(f, predeclared.Has)
+	return err
+}
+
+// parseTemplate checks syntax of mustache template in the body of the tag.
+func parseTemplate(tag *markup.TagNode) error {
+	if tag.Body == nil {
+		return nil
+	}
+	_, err := mustache.ParseStringPartials(tag.Body.Content, &mustache.StaticProvider{})
+	if err != nil {
+		msg, line := splitMustacheError(err)
+		return &ParseError{Msg: msg, Tag: tag.Name, Line: evalErrLine(tag, line)}
+	}
+	return nil
+}
+
+// lintInject checks attributes of inject tag.
+func lintInject(tag *markup.TagNode) error {
+	positions := 0
+	for _, attr := range []string{attrAfter, attrBefore} {
+		pattern := getAttr(tag, attr)
+		if pattern == "" {
+			continue
+		}
+		positions++
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %s", err)
+		}
+	}
+	for _, attr := range []string{attrAppend, attrPrepend} {
+		if _, ok := tag.Attributes[attr]; !ok {
+			continue
+		}
+		set, err := strconv.ParseBool(getAttr(tag, attr))
+		if err != nil {
+			return fmt.Errorf("attribute %q must be a boolean", attr)
+		}
+		if set {
+			positions++
+		}
+	}
+	if positions != 1 {
+		return fmt.Errorf("exactly one of attributes %q, %q, %q or %q must be set", attrAfter, attrBefore, attrAppend, attrPrepend)
+	}
+	if _, err := mustache.ParseString(getAttr(tag, attrUnlessContains)); err != nil {
+		return fmt.Errorf("invalid attribute %q: %s", attrUnlessContains, err)
+	}
+	return nil
+}
+
+// toParseError converts error found in the tag into ParseError.
+func toParseError(tag *markup.TagNode, err error) *ParseError {
+	if e, ok := err.(*ParseError); ok {
+		return e
+	}
+	return evalErr(tag, err).(*ParseError)
+}
@@ -0,0 +1,50 @@
+package blueprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var lintTests = []struct {
+	name     string
+	input    string
+	expected []*ParseError
+}{
+	{"empty", "", nil},
+	{"valid", "variable -name=\"a\" << 1 >>\nfilename << vars['a'] >>\nskipif << False >>\ntemplate << {{a}} >>", nil},
+	{"markup error", "variable -name=\"a\"\n<<", []*ParseError{{Msg: "invalid character U+003C '<'", Line: 2}}},
+	{"unknown tag", "\nunknown << 1 >>", []*ParseError{{Msg: `unknown tag "unknown"`, Tag: "unknown", Line: 2}}},
+	{"unknown attribute", "template -name=\"a\" << a >>", []*ParseError{{Msg: `unknown attribute "name"`, Tag: "template", Line: 1}}},
+	{"duplicate tag", "filename << 'a' >>\nfilename << 'b' >>", []*ParseError{{Msg: "tag is already defined", Tag: "filename", Line: 2}}},
+	{"missing name", "variable << 1 >>", []*ParseError{{Msg: `missing attribute "name"`, Tag: "variable", Line: 1}}},
+	{"syntax error", "filename <<\n    x = )\n>>", []*ParseError{{Msg: "unexpected ')'", Tag: "filename", Line: 2}}},
+	{"undefined name", "skipif << undefined >>", []*ParseError{{Msg: "undefined: undefined", Tag: "skipif", Line: 1}}},
+	{"predeclared functions", "filename << snake_case(vars['a']) >>", nil},
+	{"template error", "template <<\n{{#a}}\n>>", []*ParseError{{Msg: "Section a has no closing tag", Tag: "template", Line: 2}}},
+	{"partial without template error", "partial -name=\"p\" << {{> other}} >>", nil},
+	{"invalid inject pattern", "inject -after=\"(\" << a >>", []*ParseError{{Msg: "invalid pattern: error parsing regexp: missing closing ): `(`", Tag: "inject", Line: 1}}},
+	{"inject without position", "inject << a >>", []*ParseError{{Msg: `exactly one of attributes "after", "before", "append" or "prepend" must be set`, Tag: "inject", Line: 1}}},
+	{
+		"multiple errors",
+		"variable << x = >>\nunknown << >>",
+		[]*ParseError{
+			{Msg: `missing attribute "name"`, Tag: "variable", Line: 1},
+			{Msg: "got '=', want newline", Tag: "variable", Line: 1},
+			{Msg: `unknown tag "unknown"`, Tag: "unknown", Line: 2},
+		},
+	},
+}
+
+func TestLint(t *testing.T) {
+	for _, test := range lintTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, Lint([]byte(test.input)))
+		})
+	}
+}
+
+func TestLintModule(t *testing.T) {
+	require.NoError(t, LintModule("lib.star", []byte("def f(s):\n    return snake_case(s)\n")))
+	require.Error(t, LintModule("lib.star", []byte("def f(:\n")))
+	require.Error(t, LintModule("lib.star", []byte("x = undefined\n")))
+}
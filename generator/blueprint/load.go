@@ -0,0 +1,139 @@
+package blueprint
+
+import (
+	"errors"
+	"fmt"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"path"
+	"strings"
+)
+
+// moduleExt is the extension of starlark modules, which can be loaded in scripts.
+const moduleExt = ".star"
+
+var errLoadCycle = errors.New("cycle in load graph")
+
+// FileReader reads files from the file tree of the generator.
+type FileReader interface {
+	// ReadFile reads the file named by filename and returns the contents.
+	ReadFile(filename string) ([]byte, error)
+}
+
+// module holds globals of the loaded module, or an error,
+// if the module failed to load.
+type module struct {
+	globals starlark.StringDict
+	err     error
+}
+
+// moduleLoader loads starlark modules from the file tree of the generator
+// and caches them, so each module is executed only once.
+type moduleLoader struct {
+	fr    FileReader
+	cache map[string]*module
+}
+
+func newModuleLoader(fr FileReader) *moduleLoader {
+	return &moduleLoader{fr: fr, cache: make(map[string]*module)}
+}
+
+// loadFunc returns a function for starlark.Thread to load modules, where
+// relative module names are resolved against given directory.
+func (l *moduleLoader) loadFunc(dir string) func(*starlark.Thread, string) (starlark.StringDict, error) {
+	return func(_ *starlark.Thread, name string) (starlark.StringDict, error) {
+		p, err := resolveModulePath(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		return l.load(p)
+	}
+}
+
+// newThread creates a thread, which loads modules relative to given
+// directory and allows builtins to read files of the generator.
+func (l *moduleLoader) newThread(name, dir string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: printFunc,
+		Load:  l.loadFunc(dir),
+	}
+	thread.SetLocal(localLoader, l)
+	return thread
+}
+
+func (l *moduleLoader) load(p string) (starlark.StringDict, error) {
+	m, ok := l.cache[p]
+	if ok {
+		if m == nil {
+			return nil, errLoadCycle
+		}
+		return m.globals, m.err
+	}
+	// mark module as loading to detect cycles
+	l.cache[p] = nil
+	m = new(module)
+	b, err := l.fr.ReadFile(p)
+	if err != nil {
+		m.err = err
+	} else {
+		thread := l.newThread(p, path.Dir(p))
+		m.globals, m.err = starlark.ExecFile(thread, p, b, predeclaredFuncs())
+	}
+	l.cache[p] = m
+	return m.globals, m.err
+}
+
+// resolveModulePath converts module name into a path within the file tree
+// of the generator. Names starting with double-slash are relative to the
+// root directory of the generator, while other names are relative to given
+// directory. Paths, which evaluate outside the root directory, are rejected.
+func resolveModulePath(dir, name string) (string, error) {
+	if path.Ext(name) != moduleExt {
+		return "", fmt.Errorf("module %q must have %s extension", name, moduleExt)
+	}
+	return resolvePath("module", dir, name)
+}
+
+// resolvePath converts name of the given kind of file into a path within
+// the file tree of the generator, same as resolveModulePath.
+func resolvePath(kind, dir, name string) (string, error) {
+	var p string
+	switch {
+	case strings.HasPrefix(name, "//"):
+		p = path.Clean(name[2:])
+	case path.IsAbs(name):
+		return "", fmt.Errorf("%s %q must be relative or start with '//'", kind, name)
+	default:
+		p = path.Join(dir, name)
+	}
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(p) {
+		return "", fmt.Errorf("%s %q is outside of the generator", kind, name)
+	}
+	return p, nil
+}
+
+// hoistLoads moves load statements from the body of wrapping function
+// to the top level of the file, since starlark allows them only there.
+func hoistLoads(f *syntax.File) {
+	var loads []syntax.Stmt
+	for _, stmt := range f.Stmts {
+		def, ok := stmt.(*syntax.DefStmt)
+		if !ok {
+			continue
+		}
+		body := def.Body[:0]
+		for _, s := range def.Body {
+			if load, ok := s.(*syntax.LoadStmt); ok {
+				loads = append(loads, load)
+				continue
+			}
+			body = append(body, s)
+		}
+		if len(body) == 0 {
+			body = append(body, &syntax.BranchStmt{Token: syntax.PASS, TokenPos: def.Def})
+		}
+		def.Body = body
+	}
+	f.Stmts = append(loads, f.Stmts...)
+}
@@ -0,0 +1,128 @@
+package blueprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// fileReaderMock implements FileReader for testing and counts reads of each file.
+type fileReaderMock struct {
+	files map[string]string
+	reads map[string]int
+}
+
+func (r *fileReaderMock) ReadFile(filename string) ([]byte, error) {
+	if r.reads == nil {
+		r.reads = make(map[string]int)
+	}
+	r.reads[filename]++
+	s, ok := r.files[filename]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(s), nil
+}
+
+var moduleFiles = map[string]string{
+	"lib/strings.star": "load('helpers.star', 'exclaim')\ndef shout(s):\n    return exclaim(s.upper())\n",
+	"lib/helpers.star": "def exclaim(s):\n    return s + '!'\n",
+	"lib/cycle.star":   "load('//lib/cycle.star', 'x')\nx = 1\n",
+	"lib/escape.star":  "load('../../outside.star', 'x')\n",
+}
+
+var loadTests = []struct {
+	name     string
+	input    string
+	expected *blueprint
+	ok       bool
+}{
+	{
+		"load module",
+		`` +
+			`variable -name="var" <<` + newline +
+			`	load("//lib/strings.star", "shout")` + newline +
+			`	return shout("hi")` + newline +
+			`>>` + newline +
+			`template <<{{var}}>>`,
+		&blueprint{Body: "HI!"},
+		noError,
+	},
+	{
+		"load module with alias",
+		`` +
+			`variable -name="var" <<` + newline +
+			`	load("//lib/helpers.star", ex="exclaim")` + newline +
+			`	return ex("hi")` + newline +
+			`>>` + newline +
+			`template <<{{var}}>>`,
+		&blueprint{Body: "hi!"},
+		noError,
+	},
+	{
+		"load missing module",
+		`variable -name="var" <<` + newline + `	load("//lib/missing.star", "x")` + newline + `	return x` + newline + `>>`,
+		nil,
+		hasError,
+	},
+	{
+		"load module without extension",
+		`variable -name="var" <<` + newline + `	load("//lib/strings", "x")` + newline + `	return x` + newline + `>>`,
+		nil,
+		hasError,
+	},
+	{
+		"load module outside generator",
+		`variable -name="var" <<` + newline + `	load("../secret.star", "x")` + newline + `	return x` + newline + `>>`,
+		nil,
+		hasError,
+	},
+	{
+		"load module loading outside generator",
+		`variable -name="var" <<` + newline + `	load("//lib/escape.star", "x")` + newline + `	return x` + newline + `>>`,
+		nil,
+		hasError,
+	},
+	{
+		"load cycle",
+		`variable -name="var" <<` + newline + `	load("//lib/cycle.star", "x")` + newline + `	return x` + newline + `>>`,
+		nil,
+		hasError,
+	},
+}
+
+func TestLoad(t *testing.T) {
+	for _, test := range loadTests {
+		t.Run(test.name, func(t *testing.T) {
+			parser, err := NewParser(data{}, nopLogger{}, WithFileReader(&fileReaderMock{files: moduleFiles}))
+			require.NoError(t, err)
+			bp, err := parser.Parse([]byte(test.input))
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []*blueprint{test.expected}, bp)
+		})
+	}
+}
+
+func TestLoadCachesModules(t *testing.T) {
+	fr := &fileReaderMock{files: moduleFiles}
+	parser, err := NewParser(data{}, nopLogger{}, WithFileReader(fr))
+	require.NoError(t, err)
+	input := `variable -name="var" <<` + newline + `	load("//lib/strings.star", "shout")` + newline + `	return shout("a")` + newline + `>>`
+	for i := 0; i < 2; i++ {
+		_, err = parser.Parse([]byte(input))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, fr.reads["lib/strings.star"])
+	require.Equal(t, 1, fr.reads["lib/helpers.star"])
+}
+
+func TestLoadNotSupported(t *testing.T) {
+	parser, err := NewParser(data{}, nopLogger{})
+	require.NoError(t, err)
+	_, err = parser.Parse([]byte(`variable -name="var" <<` + newline + `	load("//lib/strings.star", "shout")` + newline + `	return 1` + newline + `>>`))
+	require.Error(t, err)
+}
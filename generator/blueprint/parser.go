@@ -6,6 +6,8 @@ import (
 	"go.starlark.net/resolve"
 	"go.starlark.net/starlark"
 	"go.starlark.net/syntax"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,13 +20,28 @@ const (
 	tagTemplate = "template"
 	tagPartial  = "partial"
 	tagVariable = "variable"
+	tagForeach  = "foreach"
+	tagInject   = "inject"
 	attrName    = "name"
+	// attributes of inject tag
+	attrAfter          = "after"
+	attrBefore         = "before"
+	attrAppend         = "append"
+	attrPrepend        = "prepend"
+	attrUnlessContains = "unless-contains"
+	// varItem is the name of variable holding current item of foreach tag.
+	varItem = "item"
+	// varCases is the name of template variable holding case
+	// variants of string variables, e.g. {{cases.name.snake}}.
+	varCases = "cases"
 )
 
 // context carries data to be used in starlark scripts and mustache templates.
 type context struct {
 	vars     map[string]starlark.Value
 	partials map[string]string
+	// loader loads starlark modules, or is nil if loading is not supported.
+	loader *moduleLoader
 }
 
 // newContext creates a context with provided data, and
@@ -123,19 +140,54 @@ type Logger interface {
 	Debug(v ...interface{})
 }
 
+// tagAttributes lists attributes supported by each tag.
+var tagAttributes = map[string][]string{
+	tagFilename: nil,
+	tagSkip:     nil,
+	tagTemplate: nil,
+	tagForeach:  nil,
+	tagPartial:  {attrName},
+	tagVariable: {attrName},
+	tagInject:   {attrAfter, attrBefore, attrAppend, attrPrepend, attrUnlessContains},
+}
+
 type Parser struct {
 	ctx context
 	mp  *markup.Parser
 	log Logger
+	// strict rejects unknown tags and attributes, duplicate tags and missing names.
+	strict bool
+}
+
+type OptionFn func(*Parser)
+
+// WithFileReader allows scripts to load starlark modules from the file
+// tree of the generator. Loaded modules are cached for the lifetime of
+// the parser.
+func WithFileReader(fr FileReader) OptionFn {
+	return func(p *Parser) {
+		p.ctx.loader = newModuleLoader(fr)
+	}
+}
+
+// Strict makes parser return an error for unknown tags and attributes,
+// duplicate filename and template tags, and variable and partial tags
+// without a name, instead of ignoring them.
+func Strict(p *Parser) {
+	p.strict = true
 }
 
-func NewParser(d map[string]interface{}, log Logger) (*Parser, error) {
+func NewParser(d map[string]interface{}, log Logger, options ...OptionFn) (*Parser, error) {
 	ctx, err := newContext(d)
 	if err != nil {
 		return nil, err
 	}
 	log.Debug("instantiating parser with data: ", d)
-	return &Parser{ctx: ctx, log: log}, nil
+	p := &Parser{ctx: ctx, log: log}
+	for _, option := range options {
+		option(p)
+	}
+	return p, nil
 }
 
 // blueprint is an alias for an anonymous struct used in
@@ -144,23 +196,47 @@ type blueprint = struct {
 	Body     string
 	Filename string
 	Skip     bool
+	Inject   []injection
 }
 
-func (p Parser) Parse(b []byte) (*blueprint, error) {
+// injection is an alias for an anonymous struct used in
+// generator.BlueprintParser interface.
+type injection = struct {
+	Content        string
+	After, Before  string // regular expressions matching the line to inject content at
+	Append         bool
+	Prepend        bool
+	UnlessContains string
+}
+
+// Parse parses blueprint and returns a list of files to generate. Blueprint
+// without foreach tag always produces a single file.
+func (p Parser) Parse(b []byte) ([]*blueprint, error) {
 	var err error
 	p.mp, err = markup.Parse(string(b), "", "")
 	if err != nil {
 		return nil, err
 	}
+	if p.strict {
+		if errs := checkStrict(p.mp.Tags); len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
 	p.ctx = p.ctx.copy()
-	return p.parse()
+	return p.parse(p.mp.Tags, blueprint{})
 }
 
-func (p *Parser) parse() (*blueprint, error) {
+// parse parses tags into blueprint, which starts with values of given
+// blueprint. Tags following foreach tag are parsed once per each item.
+func (p *Parser) parse(tags []*markup.TagNode, bp blueprint) ([]*blueprint, error) {
 	var err error
-	bp := &blueprint{}
-	for _, tag := range p.mp.Tags {
+	for i, tag := range tags {
 		switch tag.Name {
+		case tagForeach:
+			if hasEmptyBody(tag) {
+				continue
+			}
+			return p.parseForeach(tag, tags[i+1:], bp)
 		case tagVariable:
 			err = p.parseVariable(tag)
 			if err != nil {
@@ -186,9 +262,96 @@ func (p *Parser) parse() (*blueprint, error) {
 			if err != nil {
 				return nil, err
 			}
+		case tagInject:
+			in, err := p.parseInject(tag)
+			if err != nil {
+				return nil, err
+			}
+			// limit capacity, so blueprints of different foreach items don't share injections
+			bp.Inject = append(bp.Inject[:len(bp.Inject):len(bp.Inject)], in)
+		}
+	}
+	return []*blueprint{&bp}, nil
+}
+
+// checkStrict checks tags for unknown tags and attributes, duplicate
+// filename and template tags, and variable and partial tags without a name.
+func checkStrict(tags []*markup.TagNode) []*ParseError {
+	var errs []*ParseError
+	defined := make(map[string]bool)
+	for _, tag := range tags {
+		attrs, ok := tagAttributes[tag.Name]
+		if !ok {
+			errs = append(errs, newErr(fmt.Sprintf("unknown tag %q", tag.Name), tag.Name, tag.Line))
+			continue
+		}
+		names := make([]string, 0, len(tag.Attributes))
+		for name := range tag.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !contains(attrs, name) {
+				errs = append(errs, newErr(fmt.Sprintf("unknown attribute %q", name), tag.Name, tag.Line))
+			}
+		}
+		switch tag.Name {
+		case tagFilename, tagTemplate:
+			if defined[tag.Name] {
+				errs = append(errs, newErr("tag is already defined", tag.Name, tag.Line))
+			}
+			defined[tag.Name] = true
+		case tagVariable, tagPartial:
+			if isEmpty(getAttr(tag, attrName)) {
+				errs = append(errs, newErr(fmt.Sprintf("missing attribute %q", attrName), tag.Name, tag.Line))
+			}
 		}
 	}
-	return bp, nil
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForeach parses given tags once per each item of the list returned
+// by foreach tag, with the item accessible through `item` variable.
+func (p *Parser) parseForeach(tag *markup.TagNode, tags []*markup.TagNode, bp blueprint) ([]*blueprint, error) {
+	v, err := execute(parseScriptBody(tag), &p.ctx)
+	if err != nil {
+		return nil, evalErr(tag, err)
+	}
+	switch v.(type) {
+	case *starlark.List, starlark.Tuple:
+	default:
+		return nil, newErr(fmt.Sprintf("expected a list, got %s", v.Type()), tag.Name, tag.Line)
+	}
+	p.log.Debug("parsed foreach on line ", tag.Line, " with value ", v.String())
+	var bps []*blueprint
+	iter := v.(starlark.Iterable).Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		sub := *p
+		sub.ctx = p.ctx.copy()
+		sub.ctx.vars[varItem] = item
+		list, err := sub.parse(tags, bp)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range list {
+			if !b.Skip && b.Filename == "" {
+				return nil, newErr("filename must be set for each item", tag.Name, tag.Line)
+			}
+		}
+		bps = append(bps, list...)
+	}
+	return bps, nil
 }
 
 func (p *Parser) parseVariable(tag *markup.TagNode) error {
@@ -249,18 +412,75 @@ func (p *Parser) parsePartial(tag *markup.TagNode) error {
 	return nil
 }
 
+func (p *Parser) parseInject(tag *markup.TagNode) (injection, error) {
+	in := injection{
+		After:  getAttr(tag, attrAfter),
+		Before: getAttr(tag, attrBefore),
+	}
+	var err error
+	in.UnlessContains, err = p.renderRaw(getAttr(tag, attrUnlessContains))
+	if err != nil {
+		return injection{}, newErr(err.Error(), tag.Name, tag.Line)
+	}
+	// attributes are checked in fixed order, so the same error is reported each time
+	flags := []struct {
+		attr string
+		v    *bool
+	}{{attrAppend, &in.Append}, {attrPrepend, &in.Prepend}}
+	for _, f := range flags {
+		if _, ok := tag.Attributes[f.attr]; !ok {
+			continue
+		}
+		*f.v, err = strconv.ParseBool(getAttr(tag, f.attr))
+		if err != nil {
+			return injection{}, newErr(fmt.Sprintf("attribute %q must be a boolean", f.attr), tag.Name, tag.Line)
+		}
+	}
+	positions := 0
+	for _, set := range []bool{in.After != "", in.Before != "", in.Append, in.Prepend} {
+		if set {
+			positions++
+		}
+	}
+	if positions != 1 {
+		msg := fmt.Sprintf("exactly one of attributes %q, %q, %q or %q must be set", attrAfter, attrBefore, attrAppend, attrPrepend)
+		return injection{}, newErr(msg, tag.Name, tag.Line)
+	}
+	for _, pattern := range []string{in.After, in.Before} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return injection{}, newErr(fmt.Sprintf("invalid pattern: %s", err), tag.Name, tag.Line)
+		}
+	}
+	// injected content is usually code, so values are not escaped, same as in names
+	in.Content, err = p.renderBody(tag, true)
+	if err != nil {
+		return injection{}, err
+	}
+	p.log.Debug("parsed inject on line ", tag.Line)
+	return in, nil
+}
+
 func (p *Parser) renderTemplate(tag *markup.TagNode) (string, error) {
+	return p.renderBody(tag, false)
+}
+
+// renderBody renders mustache template in the body of the tag. If raw is
+// true, values are not escaped, except for values within partials.
+func (p *Parser) renderBody(tag *markup.TagNode, raw bool) (string, error) {
 	data, err := p.ctx.varsGoMap()
 	if err != nil {
 		return "", err
 	}
 	p.log.Debug("rendering template on line ", tag.Line, " with data ", data)
+	if _, ok := data[varCases]; !ok {
+		data[varCases] = stringVariants(data)
+	}
 	provider := &mustache.StaticProvider{Partials: p.ctx.partials}
 	var body string
 	if tag.Body != nil {
 		body = tag.Body.Content
 	}
-	content, err := mustache.RenderPartials(body, provider, data)
+	content, err := mustache.RenderPartialsRaw(body, provider, raw, data)
 	if err != nil {
 		msg, line := splitMustacheError(err)
 		return "", newErr(msg, tag.Name, evalErrLine(tag, line))
@@ -268,6 +488,24 @@ func (p *Parser) renderTemplate(tag *markup.TagNode) (string, error) {
 	return content, nil
 }
 
+// RenderName renders mustache template in the name of file or directory
+// with parser's data. Unlike templates, rendered values are not escaped.
+func (p *Parser) RenderName(name string) (string, error) {
+	return p.renderRaw(name)
+}
+
+// renderRaw renders mustache template with context data without escaping values.
+func (p *Parser) renderRaw(tpl string) (string, error) {
+	data, err := p.ctx.varsGoMap()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := data[varCases]; !ok {
+		data[varCases] = stringVariants(data)
+	}
+	return mustache.RenderRaw(tpl, true, data)
+}
+
 func getAttr(tag *markup.TagNode, s string) string {
 	for _, attr := range tag.Attributes {
 		if attr.Name == s {
@@ -49,6 +49,20 @@ var parseTests = []struct {
 		&blueprint{Body: "working 9 to 5"},
 		noError,
 	},
+	{
+		"template with case variants",
+		`template <<{{cases.name.snake}} {{cases.name.pascal}} {{cases.name.plural}}>>`,
+		data{"name": "userAccount"},
+		&blueprint{Body: "user_account UserAccount userAccounts"},
+		noError,
+	},
+	{
+		"template with user variable named cases",
+		`template <<{{cases}}>>`,
+		data{"cases": "test"},
+		&blueprint{Body: "test"},
+		noError,
+	},
 	{
 		"partial with global variable",
 		`` +
@@ -127,6 +141,47 @@ var parseTests = []struct {
 		&blueprint{Body: "ab"},
 		noError,
 	},
+	{
+		"global float var in template",
+		`template <<{{var}}>>`,
+		data{"var": 1.5},
+		&blueprint{Body: "1.5"},
+		noError,
+	},
+	{
+		"global map var in template",
+		`template <<{{var.a}}>>`,
+		data{"var": map[string]string{"a": "test"}},
+		&blueprint{Body: "test"},
+		noError,
+	},
+	{
+		"global float var in script",
+		`` +
+			`variable -name="var" << type(vars['var']) >>` + newline +
+			`template <<{{var}}>>`,
+		data{"var": 1.5},
+		&blueprint{Body: "float"},
+		noError,
+	},
+	{
+		"global map var in script",
+		`` +
+			`variable -name="var" << vars['var']['b'] >>` + newline +
+			`template <<{{var}}>>`,
+		data{"var": map[string]string{"a": "1", "b": "2"}},
+		&blueprint{Body: "2"},
+		noError,
+	},
+	{
+		"global mixed list var in script",
+		`` +
+			`variable -name="var" << vars['var'][1] + 1 >>` + newline +
+			`template <<{{var}}>>`,
+		data{"var": []interface{}{"a", int64(1)}},
+		&blueprint{Body: "2"},
+		noError,
+	},
 	{
 		"local integer var in template",
 		`` +
@@ -383,12 +438,168 @@ func TestParsing(t *testing.T) {
 			case test.ok:
 				assert.NoError(t, err)
 			default:
-				assert.Equal(t, test.blueprint, bp)
+				assert.Equal(t, []*blueprint{test.blueprint}, bp)
+			}
+		})
+	}
+}
+
+var foreachTests = []struct {
+	name       string
+	input      string
+	data       data
+	blueprints []*blueprint
+	ok         bool
+}{
+	{
+		"foreach over list",
+		`` +
+			`foreach << vars['entities'] >>` + newline +
+			`filename << vars['item'] + ".go" >>` + newline +
+			`template <<type {{cases.item.pascal}} struct{}>>`,
+		data{"entities": []interface{}{"user", "order"}},
+		[]*blueprint{
+			{Filename: "user.go", Body: "type User struct{}"},
+			{Filename: "order.go", Body: "type Order struct{}"},
+		},
+		noError,
+	},
+	{
+		"foreach keeps preceding tags",
+		`` +
+			`template <<shared>>` + newline +
+			`variable -name="pkg" << "app" >>` + newline +
+			`foreach << [{"name": "a"}, {"name": "b"}] >>` + newline +
+			`filename << vars['pkg'] + "/" + vars['item']['name'] >>`,
+		data{},
+		[]*blueprint{
+			{Filename: "app/a", Body: "shared"},
+			{Filename: "app/b", Body: "shared"},
+		},
+		noError,
+	},
+	{
+		"foreach with skipped item",
+		`` +
+			`foreach << ("a", "b") >>` + newline +
+			`skipif << vars['item'] == "a" >>` + newline +
+			`filename << vars['item'] >>`,
+		data{},
+		[]*blueprint{
+			{Filename: "a", Skip: true},
+			{Filename: "b"},
+		},
+		noError,
+	},
+	{
+		"foreach with item in template",
+		`` +
+			`foreach << [{"name": "a"}] >>` + newline +
+			`filename << vars['item']['name'] >>` + newline +
+			`template <<{{item.name}}>>`,
+		data{},
+		[]*blueprint{
+			{Filename: "a", Body: "a"},
+		},
+		noError,
+	},
+	{"foreach over empty list", `foreach << [] >>`, data{}, nil, noError},
+	{"foreach with empty body", `foreach << >>` + newline + `template <<test>>`, data{}, []*blueprint{{Body: "test"}}, noError},
+	{"foreach without filename", `foreach << ["a"] >>` + newline + `template <<test>>`, data{}, nil, hasError},
+	{"foreach returns string", `foreach << "abc" >>`, data{}, nil, hasError},
+	{"foreach returns dict", `foreach << {"a": 1} >>`, data{}, nil, hasError},
+}
+
+func TestForeach(t *testing.T) {
+	for _, test := range foreachTests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewParser(test.data, &nopLogger{})
+			require.NoError(t, err)
+
+			bps, err := p.Parse([]byte(test.input))
+			if !test.ok {
+				require.Error(t, err)
+				return
 			}
+			require.NoError(t, err)
+			require.Equal(t, test.blueprints, bps)
 		})
 	}
 }
 
+var injectTests = []struct {
+	name     string
+	input    string
+	expected []injection
+	ok       bool
+}{
+	{
+		"inject after",
+		`inject -after="^import \(" -unless-contains="{{pkg}}" <<` + newline + `	"{{pkg}}"` + newline + `>>`,
+		[]injection{{Content: "\t\"os\"", After: `^import \(`, UnlessContains: "os"}},
+		noError,
+	},
+	{
+		"multiple injections",
+		`inject -before="}" <<{{pkg}}>>` + newline + `inject -append="true" <<end>>` + newline + `inject -prepend="1" <<start>>`,
+		[]injection{{Content: "os", Before: "}"}, {Content: "end", Append: true}, {Content: "start", Prepend: true}},
+		noError,
+	},
+	{
+		"inject without escaping",
+		`inject -append="true" <<if {{code}} {>>`,
+		[]injection{{Content: `if x < y && s == "z" {`, Append: true}},
+		noError,
+	},
+	{"inject without position", `inject <<x>>`, nil, hasError},
+	{"inject with multiple positions", `inject -after="a" -before="b" <<x>>`, nil, hasError},
+	{"inject with false append", `inject -append="false" <<x>>`, nil, hasError},
+	{"inject with invalid append", `inject -append="yes" <<x>>`, nil, hasError},
+	{"inject with invalid pattern", `inject -after="(" <<x>>`, nil, hasError},
+}
+
+func TestInjectTag(t *testing.T) {
+	for _, test := range injectTests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewParser(data{"pkg": "os", "code": `x < y && s == "z"`}, &nopLogger{})
+			require.NoError(t, err)
+
+			bps, err := p.Parse([]byte(test.input))
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []*blueprint{{Inject: test.expected}}, bps)
+		})
+	}
+}
+
+func TestInjectTagInvalidAttributesOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		p, err := NewParser(data{}, &nopLogger{})
+		require.NoError(t, err)
+		_, err = p.Parse([]byte(`inject -append="yes" -prepend="no" <<x>>`))
+		require.EqualError(t, err, newErr(`attribute "append" must be a boolean`, tagInject, 1).Error())
+	}
+}
+
+func TestInjectTagWithForeach(t *testing.T) {
+	p, err := NewParser(data{}, &nopLogger{})
+	require.NoError(t, err)
+	input := `` +
+		`inject -append="true" <<shared>>` + newline +
+		`foreach << ["a", "b"] >>` + newline +
+		`filename << "file" >>` + newline +
+		`inject -append="true" <<{{item}}>>`
+	bps, err := p.Parse([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, []*blueprint{
+		{Filename: "file", Inject: []injection{{Content: "shared", Append: true}, {Content: "a", Append: true}}},
+		{Filename: "file", Inject: []injection{{Content: "shared", Append: true}, {Content: "b", Append: true}}},
+	}, bps)
+}
+
 var errorTests = []struct {
 	name  string
 	input string
@@ -435,3 +646,63 @@ func TestParsingDoesntModifyContext(t *testing.T) {
 	assert.Contains(t, p.ctx.vars, "var1")
 	assert.Equal(t, `"test"`, p.ctx.vars["var1"].String())
 }
+
+var renderNameTests = []struct {
+	name     string
+	data     data
+	expected string
+}{
+	{"{{name}}.go", data{"name": "a&b"}, "a&b.go"},
+	{"{{cases.name.snake}}_test.go", data{"name": "userAccount"}, "user_account_test.go"},
+	{"{{missing}}", data{}, ""},
+	{"static", data{}, "static"},
+}
+
+func TestRenderName(t *testing.T) {
+	for _, test := range renderNameTests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewParser(test.data, &nopLogger{})
+			require.NoError(t, err)
+			name, err := p.RenderName(test.name)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, name)
+		})
+	}
+}
+
+var strictTests = []struct {
+	name  string
+	input string
+	tag   string
+	line  int
+}{
+	{"unknown tag", "filname << 'a.txt' >>", "filname", 1},
+	{"unknown attribute", "variable -name=\"a\" -nme=\"b\" << 1 >>", "variable", 1},
+	{"attribute of tag without attributes", "template -name=\"a\" << a >>", "template", 1},
+	{"duplicate filename", "filename << 'a' >>\nfilename << 'b' >>", "filename", 2},
+	{"duplicate template", "template << a >>\n\ntemplate << b >>", "template", 3},
+	{"duplicate filename after foreach", "filename << 'a' >>\nforeach << [1] >>\nfilename << 'b' >>", "filename", 3},
+	{"variable without name", "variable << 1 >>", "variable", 1},
+	{"partial with empty name", "partial -name=\" \" << a >>", "partial", 1},
+}
+
+func TestStrict(t *testing.T) {
+	for _, test := range strictTests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewParser(data{}, &nopLogger{})
+			require.NoError(t, err)
+			_, err = p.Parse([]byte(test.input))
+			require.NoError(t, err)
+
+			p, err = NewParser(data{}, &nopLogger{}, Strict)
+			require.NoError(t, err)
+			_, err = p.Parse([]byte(test.input))
+			require.Error(t, err)
+			require.IsType(t, &ParseError{}, err)
+
+			e := err.(*ParseError)
+			assert.Equal(t, test.tag, e.Tag)
+			assert.Equal(t, test.line, e.Line)
+		})
+	}
+}
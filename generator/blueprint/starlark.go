@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"go.starlark.net/resolve"
 	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
 	"log"
+	"math/big"
+	"sort"
+	"strings"
 )
 
 func init() {
@@ -15,16 +19,27 @@ func init() {
 }
 
 func execute(content string, ctx *context) (starlark.Value, error) {
+	return executeWith(content, ctx, nil)
+}
+
+// executeWith is same as execute, but also predeclares given globals.
+func executeWith(content string, ctx *context, extra starlark.StringDict) (starlark.Value, error) {
 	thread := &starlark.Thread{
-		Print: func(_ *starlark.Thread, msg string) { log.Println(msg) },
+		Print: printFunc,
+	}
+	if ctx.loader != nil {
+		thread = ctx.loader.newThread("", "")
 	}
 	dict, err := ctx.varsDict()
 	if err != nil {
 		return nil, err
 	}
 	predeclared := predeclaredFuncs()
+	for k, v := range extra {
+		predeclared[k] = v
+	}
 	predeclared["vars"] = dict
-	globals, err := starlark.ExecFile(thread, "", wrapScript(content), predeclared)
+	globals, err := execScript(thread, wrapScript(content), predeclared)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +50,77 @@ func execute(content string, ctx *context) (starlark.Value, error) {
 	return val, nil
 }
 
+// execScript executes wrapped script same as starlark.ExecFile, except
+// that load statements within the wrapping function are allowed.
+func execScript(thread *starlark.Thread, src string, predeclared starlark.StringDict) (starlark.StringDict, error) {
+	f, err := syntax.Parse("", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	hoistLoads(f)
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+	globals, err := prog.Init(thread, predeclared)
+	globals.Freeze()
+	return globals, err
+}
+
+func printFunc(_ *starlark.Thread, msg string) {
+	log.Println(msg)
+}
+
+// Eval evaluates standalone starlark script with given data, accessible through
+// the special `vars` variable, and returns the result as a go value. Single
+// line script is treated as an expression, which is returned automatically,
+// while multiline script must return the value with return statement.
+func Eval(script string, data map[string]interface{}) (interface{}, error) {
+	return EvalWith(script, data, nil)
+}
+
+// EvalWith is same as Eval, but also predeclares given global variables in the script.
+func EvalWith(script string, data map[string]interface{}, globals map[string]interface{}) (interface{}, error) {
+	v, err := evalStandalone(script, data, globals)
+	if err != nil {
+		return nil, err
+	}
+	return parseValue(v)
+}
+
+// EvalBool is same as Eval, but returns the truth value of the result.
+func EvalBool(script string, data map[string]interface{}) (bool, error) {
+	v, err := evalStandalone(script, data, nil)
+	if err != nil {
+		return false, err
+	}
+	return parseBool(v), nil
+}
+
+func evalStandalone(script string, data, globals map[string]interface{}) (starlark.Value, error) {
+	ctx, err := newContext(data)
+	if err != nil {
+		return nil, err
+	}
+	predeclared := make(starlark.StringDict, len(globals))
+	for k, v := range globals {
+		if predeclared[k], err = newValue(v); err != nil {
+			return nil, err
+		}
+	}
+	if strings.Contains(strings.TrimSpace(script), "\n") {
+		script = indentScript(script)
+	} else {
+		script = wrapInlineScript(script)
+	}
+	return executeWith(script, &ctx, predeclared)
+}
+
+// indentScript indents each line of the script, so it can be wrapped within a function.
+func indentScript(c string) string {
+	return "\t" + strings.ReplaceAll(c, "\n", "\n\t")
+}
+
 // wrapScript wraps starlark code within a function, so it can be executed independently.
 func wrapScript(c string) string {
 	return fmt.Sprintf("def impl():\n%s\n", c)
@@ -48,8 +134,16 @@ func wrapInlineScript(c string) string {
 // newValue translates any supported go value into corresponding starlark value.
 func newValue(goval interface{}) (v starlark.Value, _ error) {
 	switch eval := goval.(type) {
+	case nil:
+		v = starlark.None
 	case int:
 		v = starlark.MakeInt(eval)
+	case int64:
+		v = starlark.MakeInt64(eval)
+	case *big.Int:
+		v = starlark.MakeBigInt(eval)
+	case float64:
+		v = starlark.Float(eval)
 	case string:
 		v = starlark.String(eval)
 	case bool:
@@ -60,6 +154,40 @@ func newValue(goval interface{}) (v starlark.Value, _ error) {
 			list[i] = starlark.String(s)
 		}
 		v = starlark.NewList(list)
+	case []interface{}:
+		list := make([]starlark.Value, len(eval))
+		for i, el := range eval {
+			val, err := newValue(el)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+		v = starlark.NewList(list)
+	case map[string]string:
+		m := make(map[string]interface{}, len(eval))
+		for k, s := range eval {
+			m[k] = s
+		}
+		return newValue(m)
+	case map[string]interface{}:
+		// insert keys in sorted order, so iteration over dictionary is deterministic
+		keys := make([]string, 0, len(eval))
+		for k := range eval {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(eval))
+		for _, k := range keys {
+			val, err := newValue(eval[k])
+			if err != nil {
+				return nil, err
+			}
+			if err = dict.SetKey(starlark.String(k), val); err != nil {
+				return nil, err
+			}
+		}
+		v = dict
 	default:
 		return nil, fmt.Errorf("go value can not be translated into starlark, data type %T currently is not supported", eval)
 	}
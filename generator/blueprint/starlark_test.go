@@ -0,0 +1,45 @@
+package blueprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var evalTests = []struct {
+	name     string
+	script   string
+	data     data
+	expected interface{}
+	ok       bool
+}{
+	{"expression", `1 + 1`, data{}, 2, noError},
+	{"expression with vars", `vars['name'].upper()`, data{"name": "test"}, "TEST", noError},
+	{"multiline script", "if vars['ok']:\n    return 'yes'\nreturn 'no'", data{"ok": true}, "yes", noError},
+	{"multiline script without return", "x = 1\nx += 1", data{}, "", noError},
+	{"undefined variable", `vars['undefined']`, data{}, nil, hasError},
+	{"syntax error", `1 +`, data{}, nil, hasError},
+}
+
+func TestEval(t *testing.T) {
+	for _, test := range evalTests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := Eval(test.script, test.data)
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, val)
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	ok, err := EvalBool(`vars['a'] and not vars['b']`, data{"a": true, "b": false})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = EvalBool(`vars['list']`, data{"list": []string{}})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,247 @@
+package blueprint
+
+import (
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// splitWords splits string into words by separators, which are all
+// characters except letters and digits, and by case changes, e.g.
+// "fooBar", "HTTPServer" and "foo_bar-baz" are split into two or three words.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			switch {
+			// lowercase letter or digit followed by uppercase letter, e.g. "fooBar"
+			case unicode.IsLower(prev) || unicode.IsDigit(prev):
+				flush()
+			// last letter of acronym followed by a word, e.g. "HTTPServer"
+			case unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// capitalize converts first letter of the word to uppercase and the rest to lowercase.
+func capitalize(w string) string {
+	runes := []rune(strings.ToLower(w))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// joinWords converts each word with given function and joins them with separator.
+func joinWords(s, sep string, fn func(i int, w string) string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = fn(i, w)
+	}
+	return strings.Join(words, sep)
+}
+
+func snakeCase(s string) string {
+	return joinWords(s, "_", func(_ int, w string) string { return strings.ToLower(w) })
+}
+
+func kebabCase(s string) string {
+	return joinWords(s, "-", func(_ int, w string) string { return strings.ToLower(w) })
+}
+
+func screamingSnakeCase(s string) string {
+	return joinWords(s, "_", func(_ int, w string) string { return strings.ToUpper(w) })
+}
+
+func camelCase(s string) string {
+	return joinWords(s, "", func(i int, w string) string {
+		if i == 0 {
+			return strings.ToLower(w)
+		}
+		return capitalize(w)
+	})
+}
+
+func pascalCase(s string) string {
+	return joinWords(s, "", func(_ int, w string) string { return capitalize(w) })
+}
+
+func titleCase(s string) string {
+	return joinWords(s, " ", func(_ int, w string) string { return capitalize(w) })
+}
+
+// goIdentifier converts string into a valid Go identifier by replacing invalid
+// characters with underscores, prefixing it with underscore, if it starts
+// with a digit, and suffixing it with underscore, if it's a Go keyword.
+func goIdentifier(s string) string {
+	runes := []rune(strings.TrimSpace(s))
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			runes[i] = '_'
+		}
+	}
+	id := string(runes)
+	switch {
+	case id == "":
+		return "_"
+	case unicode.IsDigit(runes[0]):
+		return "_" + id
+	case token.IsKeyword(id):
+		return id + "_"
+	}
+	return id
+}
+
+// inflection is a rule replacing the end of the word matched by pattern.
+type inflection struct {
+	pattern *regexp.Regexp
+	repl    string
+}
+
+func rules(pairs ...string) []inflection {
+	list := make([]inflection, len(pairs)/2)
+	for i := range list {
+		list[i] = inflection{regexp.MustCompile("(?i)" + pairs[i*2] + "$"), pairs[i*2+1]}
+	}
+	return list
+}
+
+// irregulars holds singular and plural forms of irregular nouns.
+var irregulars = [][2]string{
+	{"person", "people"},
+	{"man", "men"},
+	{"woman", "women"},
+	{"child", "children"},
+	{"tooth", "teeth"},
+	{"foot", "feet"},
+	{"mouse", "mice"},
+	{"goose", "geese"},
+	{"ox", "oxen"},
+}
+
+// uncountables holds nouns, which have the same singular and plural form.
+var uncountables = []string{
+	"equipment", "information", "rice", "money", "species", "series", "fish", "sheep", "deer", "news", "metadata",
+}
+
+var pluralRules = rules(
+	"(quiz)", "${1}zes",
+	"(matr|vert|ind)(?:ix|ex)", "${1}ices",
+	"(octop|vir)us", "${1}i",
+	"(alias|status|bus)", "${1}es",
+	"(x|ch|ss|sh|z)", "${1}es",
+	"([^aeiouy]|qu)y", "${1}ies",
+	"([^f])fe", "${1}ves",
+	"([lr])f", "${1}ves",
+	"sis", "ses",
+	"([ti])um", "${1}a",
+	"(buffal|tomat|potat|her)o", "${1}oes",
+	"s", "s",
+	"", "s",
+)
+
+var singularRules = rules(
+	"(quiz)zes", "${1}",
+	"(matr)ices", "${1}ix",
+	"(vert|ind)ices", "${1}ex",
+	"(octop|vir)i", "${1}us",
+	"(alias|status|bus)(?:es)?", "${1}",
+	"(x|ch|ss|sh|zz)es", "${1}",
+	"([^aeiouy]|qu)ies", "${1}y",
+	"([lr])ves", "${1}f",
+	"([^f])ves", "${1}fe",
+	"(analy|ba|diagno|parenthe|progno|synop|the)ses", "${1}sis",
+	"([ti])a", "${1}um",
+	"(buffal|tomat|potat|her)oes", "${1}o",
+	"ss", "ss",
+	"s", "",
+)
+
+func pluralize(s string) string {
+	return inflect(s, pluralRules, func(p [2]string) (string, string) { return p[0], p[1] })
+}
+
+func singularize(s string) string {
+	return inflect(s, singularRules, func(p [2]string) (string, string) { return p[1], p[0] })
+}
+
+// inflect converts the last word of the string using irregular nouns, given
+// by from and to forms, and rules. Uppercase strings are kept uppercase.
+func inflect(s string, rules []inflection, irregular func([2]string) (from, to string)) string {
+	if s == "" {
+		return s
+	}
+	for _, w := range uncountables {
+		if hasWordSuffix(s, w) {
+			return s
+		}
+	}
+	for _, p := range irregulars {
+		from, to := irregular(p)
+		if hasWordSuffix(s, from) {
+			i := len(s) - len(from)
+			return s[:i] + matchCase(s[i:], to)
+		}
+	}
+	for _, r := range rules {
+		loc := r.pattern.FindStringIndex(s)
+		if loc == nil {
+			continue
+		}
+		repl := r.pattern.ReplaceAllString(s[loc[0]:], r.repl)
+		if isUpper(s) {
+			repl = strings.ToUpper(repl)
+		}
+		return s[:loc[0]] + repl
+	}
+	return s
+}
+
+// hasWordSuffix reports whether the last word of s is given lowercase word.
+func hasWordSuffix(s, word string) bool {
+	if len(s) < len(word) || strings.ToLower(s[len(s)-len(word):]) != word {
+		return false
+	}
+	rest := s[:len(s)-len(word)]
+	if rest == "" {
+		return true
+	}
+	words := splitWords(s)
+	return len(words) > 0 && strings.EqualFold(words[len(words)-1], word)
+}
+
+// matchCase converts replacement to the same case as the original word.
+func matchCase(orig, repl string) string {
+	if isUpper(orig) {
+		return strings.ToUpper(repl)
+	}
+	runes := []rune(repl)
+	if r := []rune(orig); len(r) > 0 && unicode.IsUpper(r[0]) && len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// isUpper reports whether the word has letters and all of them are uppercase.
+func isUpper(w string) bool {
+	return strings.ToUpper(w) == w && strings.ToLower(w) != w
+}
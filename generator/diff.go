@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines surrounding changes in unified diff.
+const diffContext = 3
+
+// Change describes a file written to the filesystem.
+type Change struct {
+	Path   string
+	Exists bool   // whether file existed before it was written
+	Old    []byte // content of the file before it was written
+	New    []byte
+}
+
+// Modified reports whether content of the existing file has changed.
+func (c *Change) Modified() bool {
+	return c.Exists && !bytes.Equal(c.Old, c.New)
+}
+
+// Recorder is a Filesystem, which records changes of the files
+// written to the underlying filesystem.
+type Recorder struct {
+	Filesystem
+	changes []*Change
+	index   map[string]*Change
+	ignore  []string
+}
+
+func NewRecorder(fs Filesystem) *Recorder {
+	return &Recorder{Filesystem: fs, index: make(map[string]*Change)}
+}
+
+// WriteFile writes data to the file, recording the content of
+// the file before the first write.
+func (r *Recorder) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if r.ignored(name) {
+		return r.Filesystem.WriteFile(name, data, perm)
+	}
+	c, ok := r.index[name]
+	if !ok {
+		c = &Change{Path: name}
+		old, err := r.Filesystem.ReadFile(name)
+		switch {
+		case err == nil:
+			c.Exists, c.Old = true, old
+		case !os.IsNotExist(err):
+			return err
+		}
+	}
+	if err := r.Filesystem.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	c.New = data
+	if !ok {
+		r.index[name] = c
+		r.changes = append(r.changes, c)
+	}
+	return nil
+}
+
+// Ignore stops recording changes of the files at given paths or within
+// given directories, e.g. lock file or saved generated content, which are
+// written to the underlying filesystem as usual.
+func (r *Recorder) Ignore(paths ...string) {
+	for _, p := range paths {
+		r.ignore = append(r.ignore, filepath.Clean(p))
+	}
+}
+
+func (r *Recorder) ignored(name string) bool {
+	name = filepath.Clean(name)
+	for _, p := range r.ignore {
+		if name == p || strings.HasPrefix(name, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns changes of the written files in the order they were first written.
+func (r *Recorder) Changes() []*Change {
+	return r.changes
+}
+
+type diffLine struct {
+	op   byte // ' ' for unchanged, '-' for deleted and '+' for inserted line
+	text string
+}
+
+// UnifiedDiff returns line based changes between old and new content
+// of the file at given path in unified diff format.
+func UnifiedDiff(path string, old, new []byte) string {
+	var lines []diffLine
+	for _, d := range diff.Do(string(old), string(new)) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, l := range splitLines(d.Text) {
+			lines = append(lines, diffLine{op, l})
+		}
+	}
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		// extend hunk until changes are separated by more than twice the context
+		start, end := max(0, i-diffContext), i+1
+		for j := i; j < len(lines) && j-end <= 2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			}
+		}
+		stop := end + diffContext
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+		writeHunk(&out, lines, start, stop)
+		i = stop
+	}
+	return out.String()
+}
+
+// writeHunk writes lines between start and stop as a single hunk.
+func writeHunk(out *strings.Builder, lines []diffLine, start, stop int) {
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:start] {
+		if l.op != '+' {
+			oldStart++
+		}
+		if l.op != '-' {
+			newStart++
+		}
+	}
+	var oldLen, newLen int
+	for _, l := range lines[start:stop] {
+		if l.op != '+' {
+			oldLen++
+		}
+		if l.op != '-' {
+			newLen++
+		}
+	}
+	// empty ranges start at the line preceding them
+	if oldLen == 0 {
+		oldStart--
+	}
+	if newLen == 0 {
+		newStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+	for _, l := range lines[start:stop] {
+		out.WriteByte(l.op)
+		out.WriteString(l.text)
+		if !strings.HasSuffix(l.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var diffTests = []struct {
+	name     string
+	old, new string
+	expected string
+}{
+	{"no changes", "a\nb\n", "a\nb\n", ""},
+	{"changed line", "a\nb\nc\n", "a\nx\nc\n", "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+	{"empty old", "", "a\n", "--- a/f\n+++ b/f\n@@ -0,0 +1,1 @@\n+a\n"},
+	{"empty new", "a\n", "", "--- a/f\n+++ b/f\n@@ -1,1 +0,0 @@\n-a\n"},
+	{"no trailing newline", "a\nb", "a\nc", "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+	{
+		"context limited",
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+		"1\n2\n3\n4\nx\n6\n7\n8\n9\n",
+		"--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+	},
+	{
+		"separate hunks",
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+		"--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+	},
+	{
+		"merged hunks",
+		"1\n2\n3\n4\n5\n6\n7\n8\n",
+		"x\n2\n3\n4\n5\n6\n7\ny\n",
+		"--- a/f\n+++ b/f\n@@ -1,8 +1,8 @@\n-1\n+x\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n",
+	},
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, test := range diffTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, UnifiedDiff("f", []byte(test.old), []byte(test.new)))
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("existing", []byte("old"), 0644))
+	rec := NewRecorder(fs)
+	require.NoError(t, rec.WriteFile("existing", []byte("new"), 0644))
+	require.NoError(t, rec.WriteFile("created", []byte("a"), 0644))
+	require.NoError(t, rec.WriteFile("created", []byte("b"), 0644))
+	require.Equal(t, []*Change{
+		{Path: "existing", Exists: true, Old: []byte("old"), New: []byte("new")},
+		{Path: "created", New: []byte("b")},
+	}, rec.Changes())
+	require.True(t, rec.Changes()[0].Modified())
+	require.False(t, rec.Changes()[1].Modified())
+}
+
+func TestRecorderIgnore(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	rec := NewRecorder(fs)
+	rec.Ignore("/out/.lock", "/out/.accio/generated/")
+	require.NoError(t, rec.WriteFile("/out/.lock", []byte("lock"), 0644))
+	require.NoError(t, rec.WriteFile("/out/.accio/generated/a.txt", []byte("a"), 0644))
+	require.NoError(t, rec.WriteFile("/out/.accio/generated.txt", []byte("b"), 0644))
+	require.Equal(t, []*Change{{Path: "/out/.accio/generated.txt", New: []byte("b")}}, rec.Changes())
+	b, err := fs.ReadFile("/out/.lock")
+	require.NoError(t, err)
+	require.Equal(t, "lock", string(b))
+}
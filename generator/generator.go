@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,10 @@ import (
 
 const templateExt = ".accio"
 
+// moduleExt is the extension of starlark modules, which are loaded
+// by blueprints and are never generated.
+const moduleExt = ".star"
+
 type OptionFn func(*Runner)
 
 // OnExistsFn handles files that already exist at target path.
@@ -20,19 +25,64 @@ type OnExistsFn func(path string) bool
 // to terminate Runner and return the error.
 type OnErrorFn func(err error) bool
 
+// OnConflictFn is called for each file merged with conflicts.
+type OnConflictFn func(path string)
+
 // OnSuccessFn is called on each successfully generated file.
 // First argument holds path of the source file, and second
 // argument - path of the generated file.
 type OnSuccessFn func(src, dst string)
 
+// OnEventFn is called for each event of the files processed by Runner.
+type OnEventFn func(e Event)
+
+// EventType describes what happened to the file during run.
+type EventType string
+
+const (
+	FileCreated         EventType = "created"
+	FileOverwritten     EventType = "overwritten"
+	FileSkippedExisting EventType = "skipped-existing"
+	FileSkipped         EventType = "skipped-by-skipif"
+	FileSkippedEmpty    EventType = "skipped-empty-name" // skipped, because its name rendered empty
+	FileIgnored         EventType = "ignored"            // ignored by path, or a starlark module
+	FileErrored         EventType = "errored"
+)
+
+// Event describes what happened to a single file during run.
+type Event struct {
+	Type   EventType
+	Source string // path of the source file within generator
+	Target string // path of the generated file, or empty if it's not known
+	Err    error  // error, which occurred, if Type is FileErrored
+}
+
 type blueprint = struct {
 	Body     string
 	Filename string
 	Skip     bool
+	// Inject holds content to inject into the existing file, instead of overwriting it.
+	Inject []injection
+}
+
+// injection defines content to inject into the file at the position
+// specified by exactly one of After, Before, Append or Prepend.
+type injection = struct {
+	Content        string
+	After, Before  string // regular expressions matching the line to inject content at
+	Append         bool
+	Prepend        bool
+	UnlessContains string // skip injection, if file already contains given text
 }
 
+// BlueprintParser parses blueprint into a list of files to generate.
 type BlueprintParser interface {
-	Parse(b []byte) (*blueprint, error)
+	Parse(b []byte) ([]*blueprint, error)
+}
+
+// NameRenderer renders templates within names of files and directories.
+type NameRenderer interface {
+	RenderName(name string) (string, error)
 }
 
 // FileTreeReader is an abstraction over any system-agnostic
@@ -51,6 +101,7 @@ type FileTreeReader interface {
 }
 
 type Filesystem interface {
+	ReadFile(filename string) ([]byte, error)
 	WriteFile(name string, data []byte, perm os.FileMode) error
 	MkdirAll(path string, perm os.FileMode) error
 	Stat(name string) (os.FileInfo, error)
@@ -92,6 +143,28 @@ func SkipErrors(r *Runner) {
 	r.skipErrors = true
 }
 
+// OnError sets a function, which decides if file should be skipped when error occurs.
+// Errors are always skipped, if SkipErrors option is set.
+func OnError(fn OnErrorFn) OptionFn {
+	return func(r *Runner) {
+		r.onError = fn
+	}
+}
+
+// OnSuccess sets a function called for each created or overwritten file.
+func OnSuccess(fn OnSuccessFn) OptionFn {
+	return func(r *Runner) {
+		r.onSuccess = fn
+	}
+}
+
+// OnEvent sets a function called for each event of the processed files.
+func OnEvent(fn OnEventFn) OptionFn {
+	return func(r *Runner) {
+		r.onEvent = fn
+	}
+}
+
 func IgnorePath(p string) OptionFn {
 	p = normalizePath(p)
 	return func(r *Runner) {
@@ -105,13 +178,69 @@ func OnFileExists(fn OnExistsFn) OptionFn {
 	}
 }
 
+// SaveGenerated saves generated content of each written file in given
+// directory, relative to the write directory, so it can be used as a
+// base for merging changes on subsequent runs.
+func SaveGenerated(dir string) OptionFn {
+	return func(r *Runner) {
+		r.generatedDir = dir
+	}
+}
+
+// MergeChanges saves generated content same as SaveGenerated, and merges
+// changes into existing files with three-way merge, using saved content
+// as a base. Files without saved content are handled with OnExistsFn.
+func MergeChanges(dir string) OptionFn {
+	return func(r *Runner) {
+		r.generatedDir = dir
+		r.merge = true
+	}
+}
+
+// WriteLock records hash of each written file in given lock, and writes
+// the lock in TOML format into the file with given name in the write
+// directory, after all files are generated.
+func WriteLock(filename string, lock *Lock) OptionFn {
+	return func(r *Runner) {
+		r.lockFilename = filename
+		r.lock = lock
+	}
+}
+
+// OnMergeConflict sets callback, which is called for each
+// file merged with conflicts.
+func OnMergeConflict(fn OnConflictFn) OptionFn {
+	return func(r *Runner) {
+		r.onConflict = fn
+	}
+}
+
+// RenderNames renders templates within names of files and directories
+// with given NameRenderer, e.g. `cmd/{{name}}/main.go`.
+func RenderNames(nr NameRenderer) OptionFn {
+	return func(r *Runner) {
+		r.names = nr
+	}
+}
+
 type Runner struct {
 	fs         Filesystem
 	bluepr     BlueprintParser
+	names      NameRenderer // renders templated names, or is nil if names are literal
 	log        Logger
 	writeDir   string // absolute path to the directory to write generated files
 	skipErrors bool
 	onExists   OnExistsFn
+	onConflict OnConflictFn
+	onError    OnErrorFn
+	onSuccess  OnSuccessFn
+	onEvent    OnEventFn
+	// generatedDir is the directory relative to writeDir to save generated content, or empty if disabled
+	generatedDir string
+	merge        bool // merge changes into existing files
+	// lock records generated files and is written at lockFilename after run, or is nil if disabled
+	lock         *Lock
+	lockFilename string
 	// ignore defines files to ignore during run, where key is a filepath within generator's structure
 	ignore map[string]struct{}
 }
@@ -126,6 +255,12 @@ func NewRunner(fs Filesystem, bp BlueprintParser, dir string, options ...OptionF
 		onExists: func(_ string) bool {
 			return false
 		},
+		onConflict: func(_ string) {},
+		onError: func(_ error) bool {
+			return false
+		},
+		onSuccess: func(_, _ string) {},
+		onEvent:   func(_ Event) {},
 	}
 	for _, option := range options {
 		option(r)
@@ -136,10 +271,10 @@ func NewRunner(fs Filesystem, bp BlueprintParser, dir string, options ...OptionF
 // Run generates all the files from FileTreeReader by walking over
 // each file, reading it and writing it at relative path in working
 // directory. If file ends with extension `.accio`, then it's parsed with
-// BlueprintParser, which returns file's content and additional metadata,
-// like custom filepath, and whether file should be skipped.
+// BlueprintParser, which returns content of each file to generate and
+// additional metadata, like custom filepath, and whether file should be skipped.
 func (r *Runner) Run(ftr FileTreeReader) error {
-	return ftr.Walk(func(fpath string, isDir bool, err error) error {
+	err := ftr.Walk(func(fpath string, isDir bool, err error) error {
 		if err != nil {
 			return r.handleError(err, fpath)
 		}
@@ -147,6 +282,7 @@ func (r *Runner) Run(ftr FileTreeReader) error {
 		r.log.Debug("visiting ", fpath)
 		// skip specified files and directories
 		if _, ok := r.ignore[fpath]; ok {
+			r.onEvent(Event{Type: FileIgnored, Source: fpath})
 			if isDir {
 				r.log.Debug("skip directory")
 				return filepath.SkipDir
@@ -154,64 +290,241 @@ func (r *Runner) Run(ftr FileTreeReader) error {
 			r.log.Debug("skip file")
 			return nil
 		}
+		relpath, ok, err := r.renderPath(fpath)
+		if err != nil {
+			return r.handleError(err, fpath)
+		}
+		if !ok {
+			r.log.Debug("path renders to empty name, skip")
+			r.onEvent(Event{Type: FileSkippedEmpty, Source: fpath})
+			if isDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		// do nothing with directories
 		if isDir {
 			r.log.Debug("is a directory, do nothing")
 			return nil
 		}
+		if filepath.Ext(fpath) == moduleExt {
+			r.log.Debug("is a starlark module, skip file")
+			r.onEvent(Event{Type: FileIgnored, Source: fpath})
+			return nil
+		}
 		body, err := ftr.ReadFile(fpath)
 		if err != nil {
 			return r.handleError(err, fpath)
 		}
-		target := filepath.Join(r.writeDir, fpath)
-		r.log.Debug("file will be written at ", target)
-		if hasTemplateExtension(target) {
-			r.log.Debug("file is a blueprint, parsing...")
-			target = target[:len(target)-len(templateExt)] // remove ext
-			tpl, err := r.bluepr.Parse(body)
-			switch {
-			case err != nil:
-				return r.handleError(err, fpath)
-			case tpl.Skip:
+		target := joinWithinRoot(r.writeDir, relpath)
+		if !hasTemplateExtension(target) {
+			return r.writeFile(fpath, target, body)
+		}
+		r.log.Debug("file is a blueprint, parsing...")
+		target = target[:len(target)-len(templateExt)] // remove ext
+		tpls, err := r.bluepr.Parse(body)
+		if err != nil {
+			return r.handleError(err, fpath)
+		}
+		for _, tpl := range tpls {
+			if tpl.Skip {
 				r.log.Debug("blueprint: skipping file...")
-				return nil
-			case tpl.Filename != "":
-				basename := filepath.Base(target)
-				target = joinWithinRoot(r.writeDir, tpl.Filename)
-				stat, err := r.fs.Stat(target)
+				r.onEvent(Event{Type: FileSkipped, Source: fpath})
+				continue
+			}
+			dst := target
+			if tpl.Filename != "" {
+				dst = joinWithinRoot(r.writeDir, tpl.Filename)
+				stat, err := r.fs.Stat(dst)
 				// if path is directory, then attach filename of source file
 				if err == nil && stat.IsDir() {
-					target = filepath.Join(target, basename)
+					dst = filepath.Join(dst, filepath.Base(target))
 				}
-				r.log.Debug("blueprint: file's write destination changed to ", target)
+				r.log.Debug("blueprint: file's write destination changed to ", dst)
+			}
+			if len(tpl.Inject) > 0 {
+				err = r.injectFile(fpath, dst, tpl)
+			} else {
+				err = r.writeFile(fpath, dst, []byte(tpl.Body))
+			}
+			if err != nil {
+				return err
 			}
-			body = []byte(tpl.Body)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.writeLock()
+}
+
+// writeFile writes body of the source file at target path.
+func (r *Runner) writeFile(src, target string, body []byte) error {
+	r.log.Debug("file will be written at ", target)
+	generated := body
+	_, err := r.fs.Stat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return r.handleError(err, src)
+	}
+	if err == nil {
+		merged, ok, err := r.mergeFile(target, body)
+		switch {
+		case err != nil:
+			return r.handleError(err, src)
+		case ok:
+			body = merged
 		// if file exists, call callback to decide if it should be skipped
-		_, err = r.fs.Stat(target)
-		if err == nil && !r.onExists(target) {
+		case !r.onExists(target):
 			r.log.Debug("file already exists, skipping...")
+			r.onEvent(Event{Type: FileSkippedExisting, Source: src, Target: target})
 			return nil
 		}
-		if err != nil && !os.IsNotExist(err) {
-			return r.handleError(err, fpath)
-		}
-		err = r.fs.MkdirAll(filepath.Dir(target), 0755)
+	}
+	exists := err == nil
+	err = r.fs.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return r.handleError(err, src)
+	}
+	err = r.fs.WriteFile(target, body, 0775)
+	if err != nil {
+		return r.handleError(err, src)
+	}
+	r.log.Debug("file created at", target)
+	r.lockFile(target, body)
+	if err = r.saveBase(target, generated); err != nil {
+		return r.handleError(err, src)
+	}
+	r.generated(src, target, exists)
+	return nil
+}
+
+// mergeFile merges changes of generated body into the existing file at
+// target path with three-way merge, using previously generated content
+// as a base. It returns false, if merging is disabled, or if there's no
+// previously generated content of the file.
+func (r *Runner) mergeFile(target string, body []byte) ([]byte, bool, error) {
+	if !r.merge {
+		return nil, false, nil
+	}
+	base, err := r.fs.ReadFile(r.basePath(target))
+	if os.IsNotExist(err) {
+		r.log.Debug("no previously generated content to merge with")
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	current, err := r.fs.ReadFile(target)
+	if err != nil {
+		return nil, false, err
+	}
+	merged, conflict := merge3(string(base), string(current), string(body))
+	if conflict {
+		r.log.Debug("merged with conflicts")
+		r.onConflict(target)
+	}
+	return []byte(merged), true, nil
+}
+
+// saveBase saves generated content of the file at target path,
+// so it can be used as a base for merging, if saving is enabled.
+func (r *Runner) saveBase(target string, body []byte) error {
+	if r.generatedDir == "" {
+		return nil
+	}
+	p := r.basePath(target)
+	if err := r.fs.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return r.fs.WriteFile(p, body, 0644)
+}
+
+// basePath returns path of previously generated content of the file at target path.
+func (r *Runner) basePath(target string) string {
+	rel, err := filepath.Rel(r.writeDir, target)
+	if err != nil {
+		rel = filepath.Base(target)
+	}
+	return filepath.Join(r.writeDir, r.generatedDir, rel)
+}
+
+// injectFile injects content of the blueprint into the file at target path.
+// If file doesn't exist, then content is injected into the body of blueprint.
+func (r *Runner) injectFile(src, target string, tpl *blueprint) error {
+	r.log.Debug("injecting content into file at ", target)
+	body, err := r.fs.ReadFile(target)
+	exists := err == nil
+	switch {
+	case os.IsNotExist(err):
+		body = []byte(tpl.Body)
+	case err != nil:
+		return r.handleError(err, src)
+	}
+	result := body
+	for _, in := range tpl.Inject {
+		result, err = inject(result, in)
 		if err != nil {
-			return r.handleError(err, fpath)
+			return r.handleError(err, src)
+		}
+	}
+	if exists && bytes.Equal(result, body) {
+		r.log.Debug("nothing to inject, skipping...")
+		r.onEvent(Event{Type: FileSkippedExisting, Source: src, Target: target})
+		return nil
+	}
+	err = r.fs.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return r.handleError(err, src)
+	}
+	err = r.fs.WriteFile(target, result, 0775)
+	if err != nil {
+		return r.handleError(err, src)
+	}
+	r.log.Debug("content injected into ", target)
+	r.lockFile(target, result)
+	r.generated(src, target, exists)
+	return nil
+}
+
+// generated notifies about the successfully generated file.
+func (r *Runner) generated(src, target string, overwritten bool) {
+	typ := FileCreated
+	if overwritten {
+		typ = FileOverwritten
+	}
+	r.onEvent(Event{Type: typ, Source: src, Target: target})
+	r.onSuccess(src, target)
+}
+
+// renderPath renders templates within each segment of the path. It returns
+// false, if any of the segments renders to an empty name, meaning that the
+// file or directory should be skipped.
+func (r *Runner) renderPath(p string) (string, bool, error) {
+	if r.names == nil || !strings.Contains(p, "{{") {
+		return p, true, nil
+	}
+	segments := strings.Split(p, string(filepath.Separator))
+	for i, s := range segments {
+		if !strings.Contains(s, "{{") {
+			continue
 		}
-		err = r.fs.WriteFile(target, body, 0775)
+		name, err := r.names.RenderName(s)
 		if err != nil {
-			return r.handleError(err, fpath)
+			return "", false, err
 		}
-		r.log.Debug("file created at", fpath)
-		return nil
-	})
+		if strings.TrimSpace(name) == "" {
+			return "", false, nil
+		}
+		segments[i] = name
+	}
+	return filepath.Join(segments...), true, nil
 }
 
 func (r *Runner) handleError(err error, path string) error {
 	err = &RunError{err, path}
-	if r.skipErrors {
+	r.onEvent(Event{Type: FileErrored, Source: path, Err: err})
+	if r.skipErrors || r.onError(err) {
 		r.log.Info("ERROR: ", err.Error(), ". Skipping...")
 		return nil
 	}
@@ -2,9 +2,11 @@ package generator
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -13,16 +15,42 @@ type blueprintParserMock struct{}
 
 var _ BlueprintParser = (*blueprintParserMock)(nil)
 
-// Parse decodes json into Blueprint
-func (e *blueprintParserMock) Parse(b []byte) (*blueprint, error) {
+// Parse decodes json object into Blueprint, or json array into list of Blueprints.
+func (e *blueprintParserMock) Parse(b []byte) ([]*blueprint, error) {
+	if len(b) > 0 && b[0] == '[' {
+		var tpls []*blueprint
+		if err := json.Unmarshal(b, &tpls); err != nil {
+			return nil, err
+		}
+		return tpls, nil
+	}
 	tpl := &blueprint{}
 	err := json.Unmarshal(b, tpl)
 	if err != nil {
 		return nil, err
 	}
-	return tpl, nil
+	return []*blueprint{tpl}, nil
 }
 
+// nameRendererMock implements NameRenderer by replacing templates with given values.
+type nameRendererMock map[string]string
+
+func (m nameRendererMock) RenderName(name string) (string, error) {
+	for k, v := range m {
+		name = strings.ReplaceAll(name, k, v)
+	}
+	if strings.Contains(name, "{{") {
+		return "", errors.New("invalid template")
+	}
+	return name, nil
+}
+
+var renderNames = RenderNames(nameRendererMock{
+	"{{name}}":   "app",
+	"{{empty}}":  "",
+	"{{parent}}": "..",
+})
+
 // fileTreeReaderMock implements FileTreeReader for testing.
 type fileTreeReaderMock struct {
 	fs afero.Fs
@@ -189,6 +217,139 @@ var runnerTests = []struct {
 		[]assertFn{doesntExist("/output/ignore/a.txt"), doesntExist("/output/ignore/b.txt")},
 		[]OptionFn{IgnorePath("ignore")},
 	},
+	{
+		"blueprint with multiple files",
+		[]fsOpFn{file("/generator/file.txt.accio", `[{"Filename": "a.txt", "Body": "a"}, {"Skip": true}, {"Filename": "dir/b.txt", "Body": "b"}]`)},
+		[]assertFn{fileExists("/output/a.txt", "a"), fileExists("/output/dir/b.txt", "b"), doesntExist("/output/file.txt")},
+		[]OptionFn{},
+	},
+	{
+		"blueprint without files",
+		[]fsOpFn{file("/generator/file.txt.accio", `[]`)},
+		noOutput,
+		[]OptionFn{},
+	},
+	{
+		"render file name",
+		[]fsOpFn{file("/generator/{{name}}.txt", "test")},
+		[]assertFn{fileExists("/output/app.txt", "test")},
+		[]OptionFn{renderNames},
+	},
+	{
+		"render directory name",
+		[]fsOpFn{dir("/generator/cmd"), dir("/generator/cmd/{{name}}"), file("/generator/cmd/{{name}}/main.go", "test")},
+		[]assertFn{fileExists("/output/cmd/app/main.go", "test")},
+		[]OptionFn{renderNames},
+	},
+	{
+		"render blueprint name",
+		[]fsOpFn{file("/generator/{{name}}.txt.accio", `{"Body": "test"}`)},
+		[]assertFn{fileExists("/output/app.txt", "test")},
+		[]OptionFn{renderNames},
+	},
+	{
+		"skip file with empty name",
+		[]fsOpFn{file("/generator/{{empty}}", "test")},
+		noOutput,
+		[]OptionFn{renderNames},
+	},
+	{
+		"skip directory with empty name",
+		[]fsOpFn{dir("/generator/{{empty}}"), file("/generator/{{empty}}/a.txt", "test")},
+		noOutput,
+		[]OptionFn{renderNames},
+	},
+	{
+		"render name outside root",
+		[]fsOpFn{dir("/generator/{{parent}}"), file("/generator/{{parent}}/a.txt", "test")},
+		[]assertFn{fileExists("/output/a.txt", "test")},
+		[]OptionFn{renderNames},
+	},
+	{
+		"keep literal names without renderer",
+		[]fsOpFn{file("/generator/{{name}}.txt", "test")},
+		[]assertFn{fileExists("/output/{{name}}.txt", "test")},
+		[]OptionFn{},
+	},
+	{
+		"inject into existing file",
+		[]fsOpFn{
+			file("/generator/file.txt.accio", `{"Body": "new", "Inject": [{"Content": "b", "After": "a"}, {"Content": "c", "Append": true}]}`),
+			file("/output/file.txt", "a\nd\n"),
+		},
+		[]assertFn{fileExists("/output/file.txt", "a\nb\nd\nc\n")},
+		[]OptionFn{},
+	},
+	{
+		"inject into existing file only once",
+		[]fsOpFn{
+			file("/generator/file.txt.accio", `{"Inject": [{"Content": "b", "Append": true, "UnlessContains": "b"}]}`),
+			file("/output/file.txt", "a\nb\n"),
+		},
+		[]assertFn{fileExists("/output/file.txt", "a\nb\n")},
+		[]OptionFn{},
+	},
+	{
+		"inject into body of new file",
+		[]fsOpFn{file("/generator/file.txt.accio", `{"Body": "a\n", "Inject": [{"Content": "b", "Before": "a"}]}`)},
+		[]assertFn{fileExists("/output/file.txt", "b\na\n")},
+		[]OptionFn{},
+	},
+	{
+		"save generated content for merging",
+		[]fsOpFn{file("/generator/a.txt", "a\n")},
+		[]assertFn{fileExists("/output/a.txt", "a\n"), fileExists("/output/.base/a.txt", "a\n")},
+		[]OptionFn{MergeChanges(".base")},
+	},
+	{
+		"merge changes into modified file",
+		[]fsOpFn{
+			file("/generator/a.txt", "a\nb\nc\ny\n"),
+			file("/output/a.txt", "x\nb\nc\nd\n"),
+			file("/output/.base/a.txt", "a\nb\nc\nd\n"),
+		},
+		[]assertFn{fileExists("/output/a.txt", "x\nb\nc\ny\n"), fileExists("/output/.base/a.txt", "a\nb\nc\ny\n")},
+		[]OptionFn{MergeChanges(".base")},
+	},
+	{
+		"merge changes of blueprint",
+		[]fsOpFn{
+			file("/generator/a.txt.accio", `{"Filename": "dir/b.txt", "Body": "y\n"}`),
+			file("/output/dir/b.txt", "a\nx\n"),
+			file("/output/.base/dir/b.txt", "a\n"),
+		},
+		[]assertFn{fileExists("/output/dir/b.txt", "<<<<<<< current\na\nx\n=======\ny\n>>>>>>> generated\n")},
+		[]OptionFn{MergeChanges(".base")},
+	},
+	{
+		"save generated content without merging",
+		[]fsOpFn{
+			file("/generator/a.txt", "new\n"),
+			file("/generator/b.txt", "b\n"),
+			file("/output/a.txt", "old\n"),
+			file("/output/.base/a.txt", "base\n"),
+		},
+		[]assertFn{fileExists("/output/a.txt", "old\n"), fileExists("/output/.base/a.txt", "base\n"), fileExists("/output/.base/b.txt", "b\n")},
+		[]OptionFn{SaveGenerated(".base")},
+	},
+	{
+		"skip existing file without merge base",
+		[]fsOpFn{file("/generator/a.txt", "new"), file("/output/a.txt", "old")},
+		[]assertFn{fileExists("/output/a.txt", "old"), doesntExist("/output/.base/a.txt")},
+		[]OptionFn{MergeChanges(".base")},
+	},
+	{
+		"overwrite existing file without merge base",
+		[]fsOpFn{file("/generator/a.txt", "new"), file("/output/a.txt", "old")},
+		[]assertFn{fileExists("/output/a.txt", "new"), fileExists("/output/.base/a.txt", "new")},
+		[]OptionFn{MergeChanges(".base"), overwriteExisting},
+	},
+	{
+		"skip starlark modules",
+		[]fsOpFn{file("/generator/lib/strings.star", ""), file("/generator/file.txt", "")},
+		[]assertFn{doesntExist("/output/lib/strings.star"), fileExists("/output/file.txt", "")},
+		[]OptionFn{},
+	},
 }
 
 func TestRunner(t *testing.T) {
@@ -223,3 +384,85 @@ func TestRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderNameError(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/generator/{{invalid}}.txt", []byte("test"), 0775))
+	runner := NewRunner(fs, &blueprintParserMock{}, "/output", renderNames)
+	err := runner.Run(&fileTreeReaderMock{fs: afero.NewBasePathFs(fs, "/generator")})
+	require.Error(t, err)
+}
+
+func TestInjectError(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/generator/file.txt.accio", []byte(`{"Inject": [{"Content": "b", "After": "x"}]}`), 0775))
+	require.NoError(t, fs.WriteFile("/output/file.txt", []byte("a\n"), 0775))
+	runner := NewRunner(fs, &blueprintParserMock{}, "/output")
+	err := runner.Run(&fileTreeReaderMock{fs: afero.NewBasePathFs(fs, "/generator")})
+	require.Error(t, err)
+	fileExists("/output/file.txt", "a\n")(t, fs)
+}
+
+func TestMergeConflicts(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/generator/a.txt", []byte("b\n"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/b.txt", []byte("b\n"), 0775))
+	require.NoError(t, fs.WriteFile("/output/a.txt", []byte("x\n"), 0775))
+	require.NoError(t, fs.WriteFile("/output/.base/a.txt", []byte("a\n"), 0775))
+	require.NoError(t, fs.WriteFile("/output/b.txt", []byte("a\n"), 0775))
+	require.NoError(t, fs.WriteFile("/output/.base/b.txt", []byte("a\n"), 0775))
+	var conflicts []string
+	runner := NewRunner(fs, &blueprintParserMock{}, "/output", MergeChanges(".base"), OnMergeConflict(func(path string) {
+		conflicts = append(conflicts, path)
+	}))
+	err := runner.Run(&fileTreeReaderMock{fs: afero.NewBasePathFs(fs, "/generator")})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/output/a.txt"}, conflicts)
+	fileExists("/output/b.txt", "b\n")(t, fs)
+}
+
+func TestEvents(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/generator/a.txt", []byte("a"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/b.txt", []byte("b"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/c.txt", []byte("c"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/d.txt.accio", []byte(`{"Skip": true}`), 0775))
+	require.NoError(t, fs.WriteFile("/generator/e.txt.accio", []byte(`invalid`), 0775))
+	require.NoError(t, fs.WriteFile("/generator/f.txt", []byte("f"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/g.star", []byte("g"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/{{empty}}", []byte("h"), 0775))
+	require.NoError(t, fs.WriteFile("/output/b.txt", []byte("old"), 0775))
+	require.NoError(t, fs.WriteFile("/output/c.txt", []byte("old"), 0775))
+	var events []Event
+	var generated []string
+	runner := NewRunner(fs, &blueprintParserMock{}, "/output",
+		IgnorePath("f.txt"),
+		renderNames,
+		OnFileExists(func(path string) bool {
+			return path == "/output/b.txt"
+		}),
+		OnEvent(func(e Event) {
+			e.Err = nil
+			events = append(events, e)
+		}),
+		OnSuccess(func(src, dst string) {
+			generated = append(generated, dst)
+		}),
+		OnError(func(err error) bool {
+			return true
+		}),
+	)
+	err := runner.Run(&fileTreeReaderMock{fs: afero.NewBasePathFs(fs, "/generator")})
+	require.NoError(t, err)
+	require.Equal(t, []Event{
+		{Type: FileCreated, Source: "a.txt", Target: "/output/a.txt"},
+		{Type: FileOverwritten, Source: "b.txt", Target: "/output/b.txt"},
+		{Type: FileSkippedExisting, Source: "c.txt", Target: "/output/c.txt"},
+		{Type: FileSkipped, Source: "d.txt.accio"},
+		{Type: FileErrored, Source: "e.txt.accio"},
+		{Type: FileIgnored, Source: "f.txt"},
+		{Type: FileIgnored, Source: "g.star"},
+		{Type: FileSkippedEmpty, Source: "{{empty}}"},
+	}, events)
+	require.Equal(t, []string{"/output/a.txt", "/output/b.txt"}, generated)
+}
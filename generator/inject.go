@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// inject injects content into given file content. Content is injected as
+// whole lines, so a newline is added to the end of content, if it's missing.
+func inject(b []byte, in injection) ([]byte, error) {
+	if in.UnlessContains != "" && bytes.Contains(b, []byte(in.UnlessContains)) {
+		return b, nil
+	}
+	content := []byte(in.Content)
+	if len(content) == 0 {
+		return b, nil
+	}
+	if content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	switch {
+	case in.Prepend:
+		return insert(b, 0, content), nil
+	case in.Append:
+		return insert(b, len(b), content), nil
+	case in.After != "":
+		loc, err := find(b, in.After)
+		if err != nil {
+			return nil, err
+		}
+		// inject after the end of the matched line
+		end := loc[1]
+		if end == loc[0] || b[end-1] != '\n' {
+			if i := bytes.IndexByte(b[end:], '\n'); i >= 0 {
+				end += i + 1
+			} else {
+				end = len(b)
+			}
+		}
+		return insert(b, end, content), nil
+	case in.Before != "":
+		loc, err := find(b, in.Before)
+		if err != nil {
+			return nil, err
+		}
+		// inject before the start of the matched line
+		start := bytes.LastIndexByte(b[:loc[0]], '\n') + 1
+		return insert(b, start, content), nil
+	}
+	return nil, fmt.Errorf("injection position is not specified")
+}
+
+// find returns location of the first match of the pattern in b. Pattern
+// is matched in multi-line mode, so ^ and $ match the start and end of line.
+func find(b []byte, pattern string) ([]int, error) {
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	loc := re.FindIndex(b)
+	if loc == nil {
+		return nil, fmt.Errorf("pattern %q doesn't match any line", pattern)
+	}
+	return loc, nil
+}
+
+// insert inserts content into b at index i. If content is inserted
+// after the last line, which doesn't end with newline, then newline
+// is added to separate lines.
+func insert(b []byte, i int, content []byte) []byte {
+	result := make([]byte, 0, len(b)+len(content)+1)
+	result = append(result, b[:i]...)
+	if i > 0 && b[i-1] != '\n' {
+		result = append(result, '\n')
+	}
+	result = append(result, content...)
+	return append(result, b[i:]...)
+}
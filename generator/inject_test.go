@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const (
+	hasError = false
+	noError  = true
+)
+
+var injectTests = []struct {
+	name     string
+	input    string
+	in       injection
+	expected string
+	ok       bool
+}{
+	{"append", "a\nb\n", injection{Content: "c", Append: true}, "a\nb\nc\n", noError},
+	{"append to file without trailing newline", "a", injection{Content: "b\n", Append: true}, "a\nb\n", noError},
+	{"append to empty file", "", injection{Content: "a", Append: true}, "a\n", noError},
+	{"prepend", "a\n", injection{Content: "b", Prepend: true}, "b\na\n", noError},
+	{"after matched line", "a\nb\nc\n", injection{Content: "x", After: "^b"}, "a\nb\nx\nc\n", noError},
+	{"after match in the middle of line", "func() {\n}\n", injection{Content: "\treturn", After: `\(\)`}, "func() {\n\treturn\n}\n", noError},
+	{"after match including newline", "a\nb\n", injection{Content: "x", After: "a\n"}, "a\nx\nb\n", noError},
+	{"after last line", "a\nb", injection{Content: "x", After: "b"}, "a\nb\nx\n", noError},
+	{"after first match", "a\na\n", injection{Content: "x", After: "a"}, "a\nx\na\n", noError},
+	{"before matched line", "a\nb\n", injection{Content: "x", Before: "b"}, "a\nx\nb\n", noError},
+	{"before first line", "a\nb\n", injection{Content: "x", Before: "a"}, "x\na\nb\n", noError},
+	{"before match in the middle of line", "import (\n\t\"fmt\"\n)\n", injection{Content: "\t\"os\"", Before: `\)`}, "import (\n\t\"fmt\"\n\t\"os\"\n)\n", noError},
+	{"unless contains", "a\nx\n", injection{Content: "x", Append: true, UnlessContains: "x"}, "a\nx\n", noError},
+	{"unless doesn't contain", "a\n", injection{Content: "x", Append: true, UnlessContains: "x"}, "a\nx\n", noError},
+	{"empty content", "a\n", injection{Append: true}, "a\n", noError},
+	{"pattern doesn't match", "a\n", injection{Content: "x", After: "b"}, "", hasError},
+	{"invalid pattern", "a\n", injection{Content: "x", Before: "("}, "", hasError},
+	{"no position", "a\n", injection{Content: "x"}, "", hasError},
+}
+
+func TestInject(t *testing.T) {
+	for _, test := range injectTests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := inject([]byte(test.input), test.in)
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(b))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// Lock records which generator, answers and files produced the project.
+type Lock struct {
+	// Source is the location of the generator, either a local directory or a git repository URL.
+	Source string `toml:"source"`
+	// Commit is the hash of the commit of git repository, which the generator was read from.
+	Commit  string                 `toml:"commit,omitempty"`
+	Answers map[string]interface{} `toml:"answers"`
+	Files   []LockedFile           `toml:"files"`
+}
+
+// LockedFile records a generated file.
+type LockedFile struct {
+	// Path is the slash-separated path of the file, relative to the working directory.
+	Path string `toml:"path"`
+	// Hash is the SHA-256 hash of the content of the file, prefixed with the name of algorithm.
+	Hash string `toml:"hash"`
+}
+
+// ReadLock decodes lock from TOML.
+func ReadLock(b []byte) (*Lock, error) {
+	lock := &Lock{}
+	if _, err := toml.Decode(string(b), lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Encode encodes lock into TOML with files sorted by path.
+func (l *Lock) Encode() ([]byte, error) {
+	sort.Slice(l.Files, func(i, j int) bool {
+		return l.Files[i].Path < l.Files[j].Path
+	})
+	lock := *l
+	lock.Answers = normalizeAnswers(l.Answers)
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(lock); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeAnswers returns a copy of answers, which can be encoded into TOML.
+func normalizeAnswers(answers map[string]interface{}) map[string]interface{} {
+	if answers == nil {
+		return nil
+	}
+	normalized := make(map[string]interface{}, len(answers))
+	for k, v := range answers {
+		normalized[k] = normalizeValue(v)
+	}
+	return normalized
+}
+
+// normalizeValue converts elements of lists with values of mixed types,
+// like values of multi-choice options, to strings, since TOML arrays can't
+// hold mixed types. Prompts match string answers against the string
+// representation of option values, so such answers are read back the same.
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return normalizeAnswers(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		mixed := false
+		for i, el := range v {
+			list[i] = normalizeValue(el)
+			if reflect.TypeOf(list[i]) != reflect.TypeOf(list[0]) {
+				mixed = true
+			}
+		}
+		if !mixed {
+			return list
+		}
+		strs := make([]string, len(list))
+		for i, el := range list {
+			strs[i] = fmt.Sprint(el)
+		}
+		return strs
+	}
+	return v
+}
+
+// File returns locked file by its slash-separated path.
+func (l *Lock) File(path string) (LockedFile, bool) {
+	for _, f := range l.Files {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return LockedFile{}, false
+}
+
+// HashContent returns hash of the file content in the format used by lock.
+func HashContent(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// lockFile records content of the file written at target path.
+func (r *Runner) lockFile(target string, body []byte) {
+	if r.lock == nil {
+		return
+	}
+	rel, err := filepath.Rel(r.writeDir, target)
+	if err != nil {
+		return
+	}
+	f := LockedFile{Path: filepath.ToSlash(rel), Hash: HashContent(body)}
+	for i := range r.lock.Files {
+		if r.lock.Files[i].Path == f.Path {
+			r.lock.Files[i] = f
+			return
+		}
+	}
+	r.lock.Files = append(r.lock.Files, f)
+}
+
+// writeLock writes lock file into the write directory.
+func (r *Runner) writeLock() error {
+	if r.lock == nil {
+		return nil
+	}
+	b, err := r.lock.Encode()
+	if err != nil {
+		return err
+	}
+	return r.fs.WriteFile(filepath.Join(r.writeDir, r.lockFilename), b, 0644)
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLockEncoding(t *testing.T) {
+	lock := &Lock{
+		Source:  "github.com/user/repo",
+		Commit:  "abc",
+		Answers: map[string]interface{}{"name": "test", "count": int64(2), "tags": []interface{}{"a", "b"}},
+		Files: []LockedFile{
+			{Path: "b.txt", Hash: HashContent([]byte("b"))},
+			{Path: "a.txt", Hash: HashContent([]byte("a"))},
+		},
+	}
+	b, err := lock.Encode()
+	require.NoError(t, err)
+	require.Equal(t, ``+
+		"source = \"github.com/user/repo\"\n"+
+		"commit = \"abc\"\n"+
+		"\n"+
+		"[answers]\n"+
+		"  count = 2\n"+
+		"  name = \"test\"\n"+
+		"  tags = [\"a\", \"b\"]\n"+
+		"\n"+
+		"[[files]]\n"+
+		"  path = \"a.txt\"\n"+
+		"  hash = \"sha256:ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb\"\n"+
+		"\n"+
+		"[[files]]\n"+
+		"  path = \"b.txt\"\n"+
+		"  hash = \"sha256:3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d\"\n",
+		string(b))
+
+	decoded, err := ReadLock(b)
+	require.NoError(t, err)
+	require.Equal(t, lock, decoded)
+}
+
+func TestLockEncodingMixedTypes(t *testing.T) {
+	lock := &Lock{
+		Source: "github.com/user/repo",
+		Answers: map[string]interface{}{
+			"mixed":  []interface{}{"a", int64(1), 2.5, true},
+			"nested": map[string]interface{}{"mixed": []interface{}{1, "b"}},
+		},
+	}
+	b, err := lock.Encode()
+	require.NoError(t, err)
+	decoded, err := ReadLock(b)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"mixed":  []interface{}{"a", "1", "2.5", "true"},
+		"nested": map[string]interface{}{"mixed": []interface{}{"1", "b"}},
+	}, decoded.Answers)
+	require.Equal(t, []interface{}{"a", int64(1), 2.5, true}, lock.Answers["mixed"])
+}
+
+func TestRunnerWritesLock(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(t, fs.WriteFile("/generator/a.txt", []byte("a"), 0775))
+	require.NoError(t, fs.WriteFile("/generator/dir/b.txt.accio", []byte(`{"Body": "b"}`), 0775))
+	require.NoError(t, fs.WriteFile("/generator/skip.txt.accio", []byte(`{"Skip": true}`), 0775))
+	require.NoError(t, fs.WriteFile("/generator/existing.txt", []byte("new"), 0775))
+	require.NoError(t, fs.WriteFile("/output/existing.txt", []byte("old"), 0775))
+
+	lock := &Lock{Source: "./generator"}
+	runner := NewRunner(fs, &blueprintParserMock{}, "/output", WriteLock(".lock.toml", lock))
+	err := runner.Run(&fileTreeReaderMock{fs: afero.NewBasePathFs(fs, "/generator")})
+	require.NoError(t, err)
+
+	b, err := fs.ReadFile("/output/.lock.toml")
+	require.NoError(t, err)
+	written, err := ReadLock(b)
+	require.NoError(t, err)
+	require.Equal(t, "./generator", written.Source)
+	require.Equal(t, []LockedFile{
+		{Path: "a.txt", Hash: HashContent([]byte("a"))},
+		{Path: "dir/b.txt", Hash: HashContent([]byte("b"))},
+	}, written.Files)
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"strings"
+)
+
+// Conflict markers surrounding conflicting changes of the existing
+// file (current) and newly generated file (generated).
+const (
+	conflictStart = "<<<<<<< current\n"
+	conflictSep   = "=======\n"
+	conflictEnd   = ">>>>>>> generated\n"
+)
+
+// hunk replaces lines of the base between start and end with given lines.
+type hunk struct {
+	start, end int
+	lines      []string
+}
+
+// merge3 performs line based three-way merge of changes made to the base
+// in current and generated versions. Conflicting changes are surrounded by
+// conflict markers, in which case the second return value is true.
+func merge3(base, current, generated string) (string, bool) {
+	lines := splitLines(base)
+	cur, gen := changes(base, current), changes(base, generated)
+	var out strings.Builder
+	conflict := false
+	pos := 0
+	for len(cur) > 0 || len(gen) > 0 {
+		// group overlapping or adjacent hunks of both versions
+		var curGroup, genGroup []hunk
+		start := groupStart(cur, gen)
+		end := start
+		for {
+			switch {
+			case len(cur) > 0 && cur[0].start <= end:
+				curGroup, end = append(curGroup, cur[0]), max(end, cur[0].end)
+				cur = cur[1:]
+				continue
+			case len(gen) > 0 && gen[0].start <= end:
+				genGroup, end = append(genGroup, gen[0]), max(end, gen[0].end)
+				gen = gen[1:]
+				continue
+			}
+			break
+		}
+		out.WriteString(strings.Join(lines[pos:start], ""))
+		curText := apply(lines, start, end, curGroup)
+		genText := apply(lines, start, end, genGroup)
+		switch {
+		case len(genGroup) == 0:
+			out.WriteString(curText)
+		case len(curGroup) == 0 || curText == genText:
+			out.WriteString(genText)
+		default:
+			conflict = true
+			out.WriteString(conflictStart)
+			out.WriteString(withNewline(curText))
+			out.WriteString(conflictSep)
+			out.WriteString(withNewline(genText))
+			out.WriteString(conflictEnd)
+		}
+		pos = end
+	}
+	out.WriteString(strings.Join(lines[pos:], ""))
+	return out.String(), conflict
+}
+
+// changes returns hunks changing base into other version, ordered by position.
+func changes(base, other string) []hunk {
+	var hunks []hunk
+	var h *hunk
+	pos := 0
+	for _, d := range diff.Do(base, other) {
+		lines := splitLines(d.Text)
+		if d.Type == diffmatchpatch.DiffEqual {
+			if h != nil {
+				hunks = append(hunks, *h)
+				h = nil
+			}
+			pos += len(lines)
+			continue
+		}
+		if h == nil {
+			h = &hunk{start: pos, end: pos}
+		}
+		if d.Type == diffmatchpatch.DiffDelete {
+			pos += len(lines)
+			h.end = pos
+		} else {
+			h.lines = append(h.lines, lines...)
+		}
+	}
+	if h != nil {
+		hunks = append(hunks, *h)
+	}
+	return hunks
+}
+
+// groupStart returns the start of the first hunk of both versions.
+func groupStart(a, b []hunk) int {
+	switch {
+	case len(a) == 0:
+		return b[0].start
+	case len(b) == 0:
+		return a[0].start
+	}
+	if a[0].start < b[0].start {
+		return a[0].start
+	}
+	return b[0].start
+}
+
+// apply applies hunks to the lines of the base between start and end.
+func apply(lines []string, start, end int, hunks []hunk) string {
+	var b strings.Builder
+	pos := start
+	for _, h := range hunks {
+		b.WriteString(strings.Join(lines[pos:h.start], ""))
+		b.WriteString(strings.Join(h.lines, ""))
+		pos = h.end
+	}
+	b.WriteString(strings.Join(lines[pos:end], ""))
+	return b.String()
+}
+
+// splitLines splits text into lines, keeping line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func withNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
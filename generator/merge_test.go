@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var mergeTests = []struct {
+	name                     string
+	base, current, generated string
+	expected                 string
+	conflict                 bool
+}{
+	{"no changes", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", false},
+	{"current changed", "a\nb\n", "a\nx\n", "a\nb\n", "a\nx\n", false},
+	{"generated changed", "a\nb\n", "a\nb\n", "a\nx\n", "a\nx\n", false},
+	{"both changed same way", "a\nb\n", "a\nx\n", "a\nx\n", "a\nx\n", false},
+	{"both changed different lines", "a\nb\nc\nd\n", "x\nb\nc\nd\n", "a\nb\nc\ny\n", "x\nb\nc\ny\n", false},
+	{"lines inserted at different positions", "a\nb\nc\n", "a\nx\nb\nc\n", "a\nb\nc\ny\n", "a\nx\nb\nc\ny\n", false},
+	{"line deleted and other changed", "a\nb\nc\nd\n", "a\nc\nd\n", "a\nb\nc\ny\n", "a\nc\ny\n", false},
+	{"empty base", "", "a\n", "b\n", "<<<<<<< current\na\n=======\nb\n>>>>>>> generated\n", true},
+	{"conflicting changes", "a\nb\nc\n", "a\nx\nc\n", "a\ny\nc\n", "a\n<<<<<<< current\nx\n=======\ny\n>>>>>>> generated\nc\n", true},
+	{"conflicting insertions", "a\nc\n", "a\nx\nc\n", "a\ny\nc\n", "a\n<<<<<<< current\nx\n=======\ny\n>>>>>>> generated\nc\n", true},
+	{"conflict without trailing newline", "a\nb", "a\nx", "a\ny", "a\n<<<<<<< current\nx\n=======\ny\n>>>>>>> generated\n", true},
+	{"deleted and changed", "a\nb\nc\n", "a\nc\n", "a\ny\nc\n", "a\n<<<<<<< current\n=======\ny\n>>>>>>> generated\nc\n", true},
+}
+
+func TestMerge3(t *testing.T) {
+	for _, test := range mergeTests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflict := merge3(test.base, test.current, test.generated)
+			require.Equal(t, test.expected, merged)
+			require.Equal(t, test.conflict, conflict)
+		})
+	}
+}
@@ -57,7 +57,7 @@ func (g *Getter) Get(repourl string) (*FileTreeReader, error) {
 	}
 	g.log.Debug("cloning from ", r.raw)
 	fs := memfs.New()
-	_, err = clone(memory.NewStorage(), fs, &git.CloneOptions{
+	repo, err := clone(memory.NewStorage(), fs, &git.CloneOptions{
 		URL:               r.raw,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
 		SingleBranch:      true,
@@ -68,11 +68,20 @@ func (g *Getter) Get(repourl string) (*FileTreeReader, error) {
 	if err != nil {
 		return nil, err
 	}
+	var commit string
+	if repo != nil {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		commit = head.Hash().String()
+		g.log.Debug("resolved commit ", commit)
+	}
 	if r.subdir != "" {
 		g.log.Debug("using subdirectory ", r.subdir)
 		fs = chroot.New(fs, r.subdir)
 	}
-	return &FileTreeReader{fs}, nil
+	return &FileTreeReader{fs: fs, commit: commit}, nil
 }
 
 // WalkFunc is the type of the function called for each file or directory
@@ -94,7 +103,13 @@ type walkFunc = func(filepath string, isDir bool, err error) error
 // FileTreeReader provides an API to read directory and it's files
 // from Billy's filesystem.
 type FileTreeReader struct {
-	fs billy.Filesystem
+	fs     billy.Filesystem
+	commit string
+}
+
+// Commit returns hash of the commit, which the file tree was checked out at.
+func (r FileTreeReader) Commit() string {
+	return r.commit
 }
 
 // Walk walks the file tree, calling walkFn for each file or directory
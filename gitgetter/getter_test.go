@@ -6,6 +6,7 @@ import (
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -101,7 +102,7 @@ func TestWalkError(t *testing.T) {
 func TestGetter(t *testing.T) {
 	var options *git.CloneOptions
 
-	clone = func(s storage.Storer, worktree billy.Filesystem, o *git.CloneOptions) (*git.Repository, error) {
+	mockClone := func(s storage.Storer, worktree billy.Filesystem, o *git.CloneOptions) (*git.Repository, error) {
 		options = o
 
 		err := writeFile(worktree, "/a.txt", []byte{})
@@ -110,6 +111,7 @@ func TestGetter(t *testing.T) {
 		require.NoError(t, err)
 		return nil, nil
 	}
+	clone = mockClone
 
 	g := New(&nopLogger{})
 
@@ -125,6 +127,27 @@ func TestGetter(t *testing.T) {
 		require.Equal(t, "refs/tags/1.0", string(options.ReferenceName))
 	})
 
+	t.Run("Resolved commit", func(t *testing.T) {
+		var hash plumbing.Hash
+		clone = func(s storage.Storer, worktree billy.Filesystem, o *git.CloneOptions) (*git.Repository, error) {
+			repo, err := git.Init(s, worktree)
+			require.NoError(t, err)
+			w, err := repo.Worktree()
+			require.NoError(t, err)
+			require.NoError(t, writeFile(worktree, "/a.txt", []byte{}))
+			_, err = w.Add("a.txt")
+			require.NoError(t, err)
+			hash, err = w.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test"}})
+			require.NoError(t, err)
+			return repo, nil
+		}
+		defer func() { clone = mockClone }()
+
+		r, err := g.Get("http://test.com/")
+		require.NoError(t, err)
+		require.Equal(t, hash.String(), r.Commit())
+	})
+
 	t.Run("Subdirectory", func(t *testing.T) {
 		r, err := g.Get("http://test.com//subdir")
 		require.NoError(t, err)
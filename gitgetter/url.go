@@ -115,3 +115,31 @@ func hasSupportedUrlScheme(rawurl string) bool {
 	}
 	return false
 }
+
+// StripCredentials removes credentials from the repository URL, so it can
+// be stored safely. User name of SSH URLs is kept, since it's required to
+// authenticate with an access key, but the password is removed.
+func StripCredentials(rawurl string) string {
+	ssh := strings.HasPrefix(strings.ToLower(rawurl), "ssh://") ||
+		(!hasSupportedUrlScheme(rawurl) && detectAndNormalizeScpSshUrl(rawurl) != rawurl)
+	start := 0
+	if i := strings.Index(rawurl, "://"); i >= 0 {
+		start = i + 3
+	}
+	end := len(rawurl)
+	if i := strings.IndexRune(rawurl[start:], '/'); i >= 0 {
+		end = start + i
+	}
+	at := strings.LastIndex(rawurl[start:end], "@")
+	if at < 0 {
+		return rawurl
+	}
+	at += start
+	if !ssh {
+		return rawurl[:start] + rawurl[at+1:]
+	}
+	if i := strings.IndexRune(rawurl[start:at], ':'); i >= 0 {
+		return rawurl[:start+i] + rawurl[at:]
+	}
+	return rawurl
+}
@@ -1,16 +1,31 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"github.com/BurntSushi/toml"
+	"regexp"
 	"sort"
+	"strings"
 	"unicode"
+
+	"github.com/g1ntas/accio/generator/blueprint"
 )
 
+var errNoAnswer = errors.New("answer is missing")
+var errUnknownPrompt = errors.New("prompt is not defined in the manifest")
+var errInvalidDefault = errors.New("value is of invalid data type")
+
 type Generator struct {
 	Help    string    `toml:"help"`
 	Ignore  []string  `toml:"ignore"`
 	Prompts PromptMap `toml:"prompts"`
+	// Variables holds starlark scripts of computed variables.
+	Variables VariableMap `toml:"variables"`
+	// promptOrder holds prompt keys in the order of declaration.
+	promptOrder []string
+	// variableOrder holds variable keys in the order of declaration.
+	variableOrder []string
 }
 
 func NewGenerator() *Generator {
@@ -19,38 +34,278 @@ func NewGenerator() *Generator {
 	}
 }
 
+// VariableMap maps names of computed variables to starlark scripts,
+// which compute their values.
+type VariableMap map[string]string
+
+func (m *VariableMap) UnmarshalTOML(data interface{}) error {
+	vars, ok := data.(map[string]interface{})
+	if !ok {
+		return errors.New("variables must be defined as a table")
+	}
+	*m = make(VariableMap, len(vars))
+	for key, v := range vars {
+		if err := validateKey("variable", key); err != nil {
+			return err
+		}
+		script, ok := v.(string)
+		if !ok || isBlank(script) {
+			return fmt.Errorf("variable %q must be a non-empty starlark script", key)
+		}
+		(*m)[key] = script
+	}
+	return nil
+}
+
+// ComputeVariables evaluates scripts of computed variables in the order of
+// declaration and adds the results to given data. Each script has access to
+// the answers and previously computed variables through the `vars` variable.
+func (g *Generator) ComputeVariables(data map[string]interface{}) error {
+	for _, k := range g.VariableKeys() {
+		val, err := blueprint.Eval(g.Variables[k], data)
+		if err != nil {
+			return fmt.Errorf("computing variable %q: %w", k, err)
+		}
+		data[k] = val
+	}
+	return nil
+}
+
+// VariableKeys returns keys of computed variables in the order they were
+// declared in the manifest, or sorted alphabetically, if the order is unknown.
+func (g *Generator) VariableKeys() []string {
+	keys, i := make([]string, len(g.Variables)), 0
+	for k := range g.Variables {
+		keys[i] = k
+		i++
+	}
+	return orderedKeys(keys, g.variableOrder)
+}
+
+// PromptAll prompts the user for all prompts and returns collected data.
+// Prompts, which conditions evaluate to false, are not asked and get their
+// default value, if it's specified.
 func (g *Generator) PromptAll(prompter Prompter) (map[string]interface{}, error) {
 	data := make(map[string]interface{})
-	// sort prompts by keys, so they always appear in the same order
+	for _, k := range g.PromptKeys() {
+		ok, err := g.shouldAsk(k, data)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		if !ok {
+			continue
+		}
+		val, err := g.Prompts[k].Prompt(prompter, data)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		data[k] = val
+	}
+	return data, nil
+}
+
+// AnswerAll collects data from given answers instead of prompting the user.
+// Each answer is validated against its prompt and converted to the same
+// data type the prompt would return. Default value is used for missing
+// answers. Missing answers without defaults and answers for unknown prompts
+// are reported as errors. Answers for prompts, which conditions evaluate
+// to false, are ignored.
+func (g *Generator) AnswerAll(answers map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, k := range g.PromptKeys() {
+		ok, err := g.shouldAsk(k, data)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		if !ok {
+			continue
+		}
+		answer, ok := answers[k]
+		if !ok {
+			if answer = g.Prompts[k].Default(); answer == nil {
+				return map[string]interface{}{}, &PromptError{k, errNoAnswer}
+			}
+		}
+		val, err := g.answer(k, answer, data)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		data[k] = val
+	}
+	for _, k := range sortedKeys(answers) {
+		if _, ok := g.Prompts[k]; !ok {
+			return map[string]interface{}{}, &PromptError{k, errUnknownPrompt}
+		}
+	}
+	return data, nil
+}
+
+// PromptMissing collects data from given answers same as AnswerAll, but
+// prompts the user for prompts without an answer, e.g. prompts added in
+// a newer version of the generator. Answers for unknown prompts are ignored.
+func (g *Generator) PromptMissing(prompter Prompter, answers map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, k := range g.PromptKeys() {
+		ok, err := g.shouldAsk(k, data)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		if !ok {
+			continue
+		}
+		var val interface{}
+		if answer, ok := answers[k]; ok {
+			val, err = g.answer(k, answer, data)
+		} else {
+			val, err = g.Prompts[k].Prompt(prompter, data)
+		}
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		data[k] = val
+	}
+	return data, nil
+}
+
+// answer parses and validates the answer of the prompt with given key.
+func (g *Generator) answer(key string, answer interface{}, data map[string]interface{}) (interface{}, error) {
+	val, err := g.Prompts[key].parse(answer)
+	if err != nil {
+		return nil, &PromptError{key, err}
+	}
+	if err = g.Prompts[key].validate(val, data); err != nil {
+		return nil, &PromptError{key, err}
+	}
+	return val, nil
+}
+
+// shouldAsk evaluates the condition of the prompt against already collected
+// data and reports whether the prompt should be asked. If prompt should not be
+// asked, its default value, if any, is set in data.
+func (g *Generator) shouldAsk(key string, data map[string]interface{}) (bool, error) {
+	pr := g.Prompts[key]
+	if pr.condition() == "" {
+		return true, nil
+	}
+	ok, err := blueprint.EvalBool(pr.condition(), g.conditionData(key, data))
+	if err != nil {
+		return false, &PromptError{key, fmt.Errorf("evaluating condition: %w", err)}
+	}
+	if !ok {
+		if def := pr.Default(); def != nil {
+			data[key] = def
+		}
+	}
+	return ok, nil
+}
+
+// conditionData returns data for evaluating the condition of the prompt with
+// given key. Prompts declared before it, which were skipped and left unset,
+// are included as None, so conditions can be chained.
+func (g *Generator) conditionData(key string, data map[string]interface{}) map[string]interface{} {
+	d := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		d[k] = v
+	}
+	for _, k := range g.PromptKeys() {
+		if k == key {
+			break
+		}
+		if _, ok := d[k]; !ok {
+			d[k] = nil
+		}
+	}
+	return d
+}
+
+// SetWorkingDir sets the directory, which paths of path prompts are relative to.
+func (g *Generator) SetWorkingDir(dir string) {
+	for _, pr := range g.Prompts {
+		if p, ok := pr.(*path); ok {
+			p.dir = dir
+		}
+	}
+}
+
+// IsSecret reports whether the answer of the prompt is a secret, like a
+// password, which must not be stored.
+func (g *Generator) IsSecret(key string) bool {
+	pr, ok := g.Prompts[key]
+	return ok && pr.kind() == promptPassword
+}
+
+// PromptKeys returns keys of all prompts in the order they were declared
+// in the manifest. If the order is unknown or doesn't match the prompts,
+// keys are sorted alphabetically, so prompts always appear in the same order.
+func (g *Generator) PromptKeys() []string {
 	keys, i := make([]string, len(g.Prompts)), 0
 	for k := range g.Prompts {
 		keys[i] = k
 		i++
 	}
+	return orderedKeys(keys, g.promptOrder)
+}
+
+// orderedKeys returns a copy of given order, if it holds exactly the given
+// keys, or keys sorted alphabetically otherwise.
+func orderedKeys(keys, order []string) []string {
 	sort.Strings(keys)
-	for _, k := range keys {
-		val, err := g.Prompts[k].Prompt(prompter)
-		if err != nil {
-			return map[string]interface{}{}, err
+	if len(order) != len(keys) {
+		return keys
+	}
+	sorted := make([]string, len(order))
+	copy(sorted, order)
+	sort.Strings(sorted)
+	for i := range keys {
+		if keys[i] != sorted[i] {
+			return keys
 		}
-		data[k] = val
 	}
-	return data, nil
+	copy(sorted, order)
+	return sorted
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys, i := make([]string, len(m)), 0
+	for k := range m {
+		keys[i] = k
+		i++
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func ReadToml(b []byte) (*Generator, error) {
 	g := NewGenerator()
-	err := toml.Unmarshal(b, &g)
+	md, err := toml.Decode(string(b), &g)
 	if err != nil {
 		return nil, err
 	}
+	g.promptOrder = declaredKeys(md, "prompts")
+	g.variableOrder = declaredKeys(md, "variables")
+	for k := range g.Variables {
+		if _, ok := g.Prompts[k]; ok {
+			return nil, fmt.Errorf("variable %q has the same name as a prompt", k)
+		}
+	}
 	return g, nil
 }
 
+// declaredKeys returns keys of given table in the order they appear in the TOML document.
+func declaredKeys(md toml.MetaData, table string) []string {
+	var keys []string
+	for _, k := range md.Keys() {
+		if len(k) == 2 && k[0] == table {
+			keys = append(keys, k[1])
+		}
+	}
+	return keys
+}
+
 func (m PromptMap) UnmarshalTOML(data interface{}) error {
 	prompts := data.(map[string]interface{})
 	for key, def := range prompts {
-		if err := validatePromptKey(key); err != nil {
+		if err := validateKey("prompt", key); err != nil {
 			return err
 		}
 		mapping := def.(map[string]interface{})
@@ -64,55 +319,283 @@ func (m PromptMap) UnmarshalTOML(data interface{}) error {
 			return err
 		}
 		base.HelpText, _ = mapping["help"].(string)
+		if base.When, err = parsePromptScript(mapping, "when", key); err != nil {
+			return err
+		}
+		if base.ValidateScript, err = parsePromptScript(mapping, "validate", key); err != nil {
+			return err
+		}
+		var pr Prompt
 		switch typ {
 		case promptInput:
-			m[key] = &input{base}
+			p := &input{Base: base}
+			if p.pattern, err = parsePromptPattern(mapping, key); err != nil {
+				return err
+			}
+			if p.minLength, p.maxLength, err = parsePromptBounds(mapping, "min_length", "max_length", key); err != nil {
+				return err
+			}
+			pr = p
 		case promptInteger:
-			m[key] = &integer{base}
+			p := &integer{Base: base}
+			if p.min, err = parseIntOption(mapping, "min", key); err != nil {
+				return err
+			}
+			if p.max, err = parseIntOption(mapping, "max", key); err != nil {
+				return err
+			}
+			if p.min != nil && p.max != nil && *p.min > *p.max {
+				return fmt.Errorf("option 'min' is greater than option 'max' for prompt %q", key)
+			}
+			pr = p
 		case promptConfirm:
-			m[key] = &confirm{base}
+			pr = &confirm{base}
+		case promptPassword:
+			pr = &password{base}
+		case promptFloat:
+			pr = &float{base}
+		case promptPath:
+			p := &path{Base: base}
+			if p.mustExist, err = parseBoolOption(mapping, "must_exist", key); err != nil {
+				return err
+			}
+			pr = p
+		case promptList:
+			pr = &list{base}
+		case promptMap:
+			pr = &keyValueMap{base}
 		case promptChoice, promptMultiChoice:
 			opts, err := parsePromptOptions(mapping, key)
 			if err != nil {
 				return err
 			}
 			if typ == promptChoice {
-				m[key] = &choice{base, opts}
-			} else {
-				m[key] = &multiChoice{base, opts}
+				pr = &choice{base, opts}
+				break
+			}
+			p := &multiChoice{Base: base, options: opts}
+			if p.minSelected, p.maxSelected, err = parsePromptBounds(mapping, "min_selected", "max_selected", key); err != nil {
+				return err
 			}
+			pr = p
 		default:
 			return fmt.Errorf("unknown type %q in prompt %q", typ, key)
 		}
+		if base.ValidateScript != "" && !supportsValidateScript(typ) {
+			return fmt.Errorf("option 'validate' is not supported by prompt %q of type %q", key, typ)
+		}
+		if err := parsePromptDefault(pr, mapping, key); err != nil {
+			return err
+		}
+		m[key] = pr
+	}
+	return nil
+}
+
+// parsePromptScript returns starlark script from given option or empty string, if it's not specified.
+func parsePromptScript(conf map[string]interface{}, option, key string) (string, error) {
+	v, ok := conf[option]
+	if !ok {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok || isBlank(s) {
+		return "", fmt.Errorf("option '%s' for prompt %q must be a non-empty string", option, key)
+	}
+	return s, nil
+}
+
+// parsePromptPattern returns compiled pattern or nil, if it's not specified.
+func parsePromptPattern(conf map[string]interface{}, key string) (*regexp.Regexp, error) {
+	v, ok := conf["pattern"]
+	if !ok {
+		return nil, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("option 'pattern' for prompt %q must be a string", key)
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for prompt %q: %w", key, err)
+	}
+	return re, nil
+}
+
+// parsePromptBounds parses two options defining the minimum and maximum
+// count of something. Options, which are not specified, are returned as 0.
+func parsePromptBounds(conf map[string]interface{}, minOption, maxOption, key string) (min, max int, err error) {
+	for _, o := range []struct {
+		name string
+		val  *int
+	}{{minOption, &min}, {maxOption, &max}} {
+		i, err := parseIntOption(conf, o.name, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		if i == nil {
+			continue
+		}
+		if *i < 0 {
+			return 0, 0, fmt.Errorf("option '%s' for prompt %q must not be negative", o.name, key)
+		}
+		*o.val = *i
+	}
+	if max > 0 && min > max {
+		return 0, 0, fmt.Errorf("option '%s' is greater than option '%s' for prompt %q", minOption, maxOption, key)
+	}
+	return min, max, nil
+}
+
+func parseBoolOption(conf map[string]interface{}, option, key string) (bool, error) {
+	v, ok := conf[option]
+	if !ok {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("option '%s' for prompt %q must be a boolean", option, key)
+	}
+	return b, nil
+}
+
+// supportsValidateScript reports whether prompt of given type can be validated with a script.
+func supportsValidateScript(typ string) bool {
+	switch typ {
+	case promptInput, promptInteger, promptMultiChoice, promptPassword, promptFloat, promptPath:
+		return true
+	}
+	return false
+}
+
+// acceptsStringDefault reports whether prompt of given type expects default value to be a string.
+func acceptsStringDefault(typ string) bool {
+	switch typ {
+	case promptInput, promptChoice, promptPath:
+		return true
+	}
+	return false
+}
+
+// parseIntOption returns integer option or nil, if it's not specified.
+func parseIntOption(conf map[string]interface{}, option, key string) (*int, error) {
+	v, ok := conf[option]
+	if !ok {
+		return nil, nil
+	}
+	i, ok := v.(int64)
+	if !ok || int64(int(i)) != i {
+		return nil, fmt.Errorf("option '%s' for prompt %q must be an integer", option, key)
+	}
+	n := int(i)
+	return &n, nil
+}
+
+// parsePromptDefault validates default value of the prompt, if it's
+// specified, against the static validation rules of the prompt, and sets
+// it converted to the data type of the prompt.
+func parsePromptDefault(pr Prompt, conf map[string]interface{}, key string) error {
+	def, ok := conf["default"]
+	if !ok {
+		return nil
+	}
+	if _, isStr := def.(string); isStr && !acceptsStringDefault(pr.kind()) {
+		return fmt.Errorf("invalid default value for prompt %q: %s", key, errInvalidDefault)
+	}
+	if pr.kind() == promptPassword {
+		return fmt.Errorf("prompt %q of type %q can not have a default value", key, promptPassword)
+	}
+	val, err := pr.parse(def)
+	if err != nil {
+		return fmt.Errorf("invalid default value for prompt %q: %w", key, err)
+	}
+	if v, ok := pr.(ruleValidator); ok {
+		if err = v.validateRules(val); err != nil {
+			return fmt.Errorf("invalid default value for prompt %q: %w", key, err)
+		}
 	}
+	pr.setDefault(val)
 	return nil
 }
 
-func parsePromptOptions(conf map[string]interface{}, key string) ([]string, error) {
+func parsePromptOptions(conf map[string]interface{}, key string) (options, error) {
 	opts, ok := conf["options"].([]interface{})
+	// array of tables is decoded into a list of maps
+	if tables, isTables := conf["options"].([]map[string]interface{}); isTables {
+		opts, ok = make([]interface{}, len(tables)), true
+		for i, t := range tables {
+			opts[i] = t
+		}
+	}
 	if !ok || len(opts) == 0 {
-		return []string{}, fmt.Errorf("options for prompt %q were not specified or are invalid", key)
+		return options{}, fmt.Errorf("options for prompt %q were not specified or are invalid", key)
 	}
-	options := make([]string, len(opts))
+	list := make(options, len(opts))
+	labels := make(map[string]struct{}, len(opts))
+	values := make(map[interface{}]struct{}, len(opts))
 	for i, v := range opts {
-		options[i], ok = v.(string)
-		if !ok {
-			return []string{}, fmt.Errorf("encountered non-string element while parsing options for prompt %q, make sure that all options are of type string", key)
+		switch opt := v.(type) {
+		case string:
+			list[i] = option{label: opt, value: opt}
+		case map[string]interface{}:
+			o, err := parsePromptOption(opt, key)
+			if err != nil {
+				return options{}, err
+			}
+			list[i] = o
+		default:
+			return options{}, fmt.Errorf("encountered invalid element while parsing options for prompt %q, make sure that all options are strings or tables", key)
+		}
+		if _, ok := labels[list[i].label]; ok {
+			return options{}, fmt.Errorf("option %q is defined more than once for prompt %q", list[i].label, key)
+		}
+		labels[list[i].label] = struct{}{}
+		if _, ok := values[list[i].value]; ok {
+			return options{}, fmt.Errorf("option value %v is defined more than once for prompt %q", list[i].value, key)
+		}
+		values[list[i].value] = struct{}{}
+	}
+	return list, nil
+}
+
+// parsePromptOption parses option defined as a table with label, value and help keys.
+// If value is not specified, label is used as a value.
+func parsePromptOption(conf map[string]interface{}, key string) (option, error) {
+	label, _ := conf["label"].(string)
+	if isBlank(label) {
+		return option{}, fmt.Errorf("option of prompt %q has no label", key)
+	}
+	o := option{label: label, value: label}
+	o.help, _ = conf["help"].(string)
+	v, ok := conf["value"]
+	if !ok {
+		return o, nil
+	}
+	switch val := v.(type) {
+	case string, float64, bool:
+		o.value = val
+	case int64:
+		if int64(int(val)) != val {
+			return option{}, fmt.Errorf("value of option %q for prompt %q is too long", label, key)
 		}
+		o.value = int(val)
+	default:
+		return option{}, fmt.Errorf("value of option %q for prompt %q must be a string, number or boolean", label, key)
 	}
-	return options, nil
+	return o, nil
 }
 
-func validatePromptKey(k string) error {
+// validateKey checks whether k is a valid key of given kind, e.g. prompt or variable.
+func validateKey(kind, k string) error {
 	if len(k) > 64 {
-		return fmt.Errorf("prompt key %q is too long, it must be not longer than 64 characters", k)
+		return fmt.Errorf("%s key %q is too long, it must be not longer than 64 characters", kind, k)
 	}
 	if isDigit(rune(k[0])) {
-		return fmt.Errorf("prompt key %q should start with a letter or underscore, but got digit instead", k)
+		return fmt.Errorf("%s key %q should start with a letter or underscore, but got digit instead", kind, k)
 	}
 	for _, r := range k {
 		if !isLetter(r) && !isDigit(r) && r != '_' {
-			return fmt.Errorf("prompt key %q contains invalid character %q", k, r)
+			return fmt.Errorf("%s key %q contains invalid character %q", kind, k, r)
 		}
 	}
 	return nil
@@ -129,6 +612,10 @@ func parsePromptMessage(conf map[string]interface{}, key string) (string, error)
 	return s, nil
 }
 
+func isBlank(s string) bool {
+	return len(strings.TrimSpace(s)) == 0
+}
+
 // isLetter checks whether r is an ASCII valid letter ([a-zA-Z]).
 func isLetter(r rune) bool {
 	return r <= unicode.MaxASCII && unicode.IsLetter(r)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/require"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -43,6 +44,38 @@ var configTests = []struct {
 		noError,
 	},
 
+	// variables
+	{
+		"variables",
+		conf{"variables": conf{"name": "vars['a']"}},
+		&Generator{Prompts: PromptMap{}, Variables: VariableMap{"name": "vars['a']"}, variableOrder: []string{"name"}},
+		noError,
+	},
+	{
+		"Variable non-string script",
+		conf{"variables": conf{"name": 1}},
+		nil,
+		hasError,
+	},
+	{
+		"Variable empty script",
+		conf{"variables": conf{"name": " "}},
+		nil,
+		hasError,
+	},
+	{
+		"Variable invalid name",
+		conf{"variables": conf{"1name": "1"}},
+		nil,
+		hasError,
+	},
+	{
+		"Variable with the same name as prompt",
+		conf{"variables": conf{"var": "1"}, "prompts": conf{"var": conf{"type": "input", "message": "test"}}},
+		nil,
+		hasError,
+	},
+
 	// prompts
 	{
 		"Prompt empty type",
@@ -77,7 +110,7 @@ var configTests = []struct {
 	{
 		"Prompt with valid var name",
 		conf{"prompts": conf{"_Var_1": conf{"type": "input", "message": "test"}}},
-		&Generator{Prompts: PromptMap{"_Var_1": &input{Base{Msg: "test"}}}},
+		&Generator{promptOrder: []string{"_Var_1"}, Prompts: PromptMap{"_Var_1": &input{Base: Base{Msg: "test"}}}},
 		noError,
 	},
 	{
@@ -95,25 +128,25 @@ var configTests = []struct {
 	{
 		"Prompt type input",
 		conf{"prompts": conf{"var": conf{"type": "input", "message": "test"}}},
-		&Generator{Prompts: PromptMap{"var": &input{Base{Msg: "test"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &input{Base: Base{Msg: "test"}}}},
 		noError,
 	},
 	{
 		"Prompt help",
 		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "help": "abc"}}},
-		&Generator{Prompts: PromptMap{"var": &input{Base{Msg: "test", HelpText: "abc"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &input{Base: Base{Msg: "test", HelpText: "abc"}}}},
 		noError,
 	},
 	{
 		"Prompt type integer",
 		conf{"name": "a", "prompts": conf{"var": conf{"type": "integer", "message": "test"}}},
-		&Generator{Prompts: PromptMap{"var": &integer{Base{Msg: "test"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &integer{Base: Base{Msg: "test"}}}},
 		noError,
 	},
 	{
 		"Prompt type confirm",
 		conf{"prompts": conf{"var": conf{"type": "confirm", "message": "test"}}},
-		&Generator{Prompts: PromptMap{"var": &confirm{Base{Msg: "test"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &confirm{Base{Msg: "test"}}}},
 		noError,
 	},
 	{
@@ -123,9 +156,9 @@ var configTests = []struct {
 			"options": []string{"a", "b"},
 			"message": "test",
 		}}},
-		&Generator{Prompts: PromptMap{"var": &choice{
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &choice{
 			Base{Msg: "test"},
-			[]string{"a", "b"},
+			stringOptions("a", "b"),
 		},
 		}},
 		noError,
@@ -166,13 +199,310 @@ var configTests = []struct {
 			"options": []string{"a", "b"},
 			"message": "test",
 		}}},
-		&Generator{Prompts: PromptMap{"var": &multiChoice{
-			Base{Msg: "test"},
-			[]string{"a", "b"},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &multiChoice{
+			Base:    Base{Msg: "test"},
+			options: stringOptions("a", "b"),
 		},
 		}},
 		noError,
 	},
+	{
+		"Prompt input default",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "default": "abc"}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &input{Base: Base{Msg: "test", DefaultValue: "abc"}}}},
+		noError,
+	},
+	{
+		"Prompt integer default",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "default": 5}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &integer{Base: Base{Msg: "test", DefaultValue: 5}}}},
+		noError,
+	},
+	{
+		"Prompt integer default of invalid type",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "default": "5"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt confirm default",
+		conf{"prompts": conf{"var": conf{"type": "confirm", "message": "test", "default": true}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &confirm{Base{Msg: "test", DefaultValue: true}}}},
+		noError,
+	},
+	{
+		"Prompt choice default",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []string{"a", "b"},
+			"message": "test",
+			"default": "b",
+		}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &choice{
+			Base{Msg: "test", DefaultValue: "b"},
+			stringOptions("a", "b"),
+		},
+		}},
+		noError,
+	},
+	{
+		"Prompt choice default is not an option",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []string{"a", "b"},
+			"message": "test",
+			"default": "c",
+		}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt multi-choice default",
+		conf{"prompts": conf{"var": conf{
+			"type":    "multi-choice",
+			"options": []string{"a", "b"},
+			"message": "test",
+			"default": []string{"a"},
+		}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &multiChoice{
+			Base:    Base{Msg: "test", DefaultValue: []string{"a"}},
+			options: stringOptions("a", "b"),
+		},
+		}},
+		noError,
+	},
+	{
+		"Prompt condition",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "when": "vars['a']"}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &input{Base: Base{Msg: "test", When: "vars['a']"}}}},
+		noError,
+	},
+	{
+		"Prompt empty condition",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "when": " "}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt non-string condition",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "when": true}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt input validation rules",
+		conf{"prompts": conf{"var": conf{
+			"type":       "input",
+			"message":    "test",
+			"pattern":    "^[a-z]+$",
+			"min_length": 1,
+			"max_length": 10,
+			"validate":   "None",
+		}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &input{
+			Base:      Base{Msg: "test", ValidateScript: "None"},
+			pattern:   regexp.MustCompile("^[a-z]+$"),
+			minLength: 1,
+			maxLength: 10,
+		}}},
+		noError,
+	},
+	{
+		"Prompt input invalid pattern",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "pattern": "[a-z"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt input min length greater than max length",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "min_length": 2, "max_length": 1}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt input default not matching pattern",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "pattern": "^[a-z]+$", "default": "A"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt integer default less than min",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "min": 5, "default": 1}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt multi-choice default with too few options selected",
+		conf{"prompts": conf{"var": conf{
+			"type":         "multi-choice",
+			"message":      "test",
+			"options":      []string{"a", "b"},
+			"min_selected": 2,
+			"default":      []string{"a"},
+		}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt input negative min length",
+		conf{"prompts": conf{"var": conf{"type": "input", "message": "test", "min_length": -1}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt integer validation rules",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "min": 0, "max": 10}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &integer{
+			Base: Base{Msg: "test"},
+			min:  intPtr(0),
+			max:  intPtr(10),
+		}}},
+		noError,
+	},
+	{
+		"Prompt integer min greater than max",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "min": 10, "max": 0}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt integer non-integer min",
+		conf{"prompts": conf{"var": conf{"type": "integer", "message": "test", "min": "0"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt multi-choice validation rules",
+		conf{"prompts": conf{"var": conf{
+			"type":         "multi-choice",
+			"options":      []string{"a", "b"},
+			"message":      "test",
+			"min_selected": 1,
+			"max_selected": 2,
+		}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &multiChoice{
+			Base:        Base{Msg: "test"},
+			options:     stringOptions("a", "b"),
+			minSelected: 1,
+			maxSelected: 2,
+		}}},
+		noError,
+	},
+	{
+		"Prompt confirm with validation script",
+		conf{"prompts": conf{"var": conf{"type": "confirm", "message": "test", "validate": "None"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt type password",
+		conf{"prompts": conf{"var": conf{"type": "password", "message": "test"}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &password{Base{Msg: "test"}}}},
+		noError,
+	},
+	{
+		"Prompt password with default",
+		conf{"prompts": conf{"var": conf{"type": "password", "message": "test", "default": "secret"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt type float",
+		conf{"prompts": conf{"var": conf{"type": "float", "message": "test", "default": 1.5}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &float{Base{Msg: "test", DefaultValue: 1.5}}}},
+		noError,
+	},
+	{
+		"Prompt type path",
+		conf{"prompts": conf{"var": conf{"type": "path", "message": "test", "must_exist": true, "default": "./dir/"}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &path{
+			Base:      Base{Msg: "test", DefaultValue: "dir"},
+			mustExist: true,
+		}}},
+		noError,
+	},
+	{
+		"Prompt path with non-boolean must_exist",
+		conf{"prompts": conf{"var": conf{"type": "path", "message": "test", "must_exist": "yes"}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt type list",
+		conf{"prompts": conf{"var": conf{"type": "list", "message": "test", "default": []string{"a"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &list{Base{Msg: "test", DefaultValue: []string{"a"}}}}},
+		noError,
+	},
+	{
+		"Prompt type map",
+		conf{"prompts": conf{"var": conf{"type": "map", "message": "test", "default": conf{"a": "1"}}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &keyValueMap{
+			Base{Msg: "test", DefaultValue: map[string]string{"a": "1"}},
+		}}},
+		noError,
+	},
+	{
+		"Prompt choice with labeled options",
+		conf{"prompts": conf{"var": conf{
+			"type": "choice",
+			"options": []conf{
+				{"label": "MIT License", "value": "mit", "help": "permissive"},
+				{"label": "Version 2", "value": 2},
+				{"label": "Other"},
+			},
+			"message": "test",
+			"default": "mit",
+		}}},
+		&Generator{promptOrder: []string{"var"}, Prompts: PromptMap{"var": &choice{
+			Base{Msg: "test", DefaultValue: "mit"},
+			options{
+				{label: "MIT License", value: "mit", help: "permissive"},
+				{label: "Version 2", value: 2},
+				{label: "Other", value: "Other"},
+			},
+		}}},
+		noError,
+	},
+	{
+		"Prompt choice option without label",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []conf{{"value": "mit"}},
+			"message": "test",
+		}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt choice option with non-scalar value",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []conf{{"label": "a", "value": []string{"a"}}},
+			"message": "test",
+		}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt choice with duplicate options",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []string{"a", "a"},
+			"message": "test",
+		}}},
+		nil,
+		hasError,
+	},
+	{
+		"Prompt choice with duplicate option values",
+		conf{"prompts": conf{"var": conf{
+			"type":    "choice",
+			"options": []conf{{"label": "a", "value": "x"}, {"label": "b", "value": "x"}},
+			"message": "test",
+		}}},
+		nil,
+		hasError,
+	},
 	{
 		"Prompt 'multi-choice' without options",
 		conf{"prompts": conf{"var": conf{
@@ -184,6 +514,46 @@ var configTests = []struct {
 	},
 }
 
+func TestPromptDeclarationOrder(t *testing.T) {
+	gen, err := ReadToml([]byte(`
+[prompts.b]
+type="input"
+message="test"
+
+[prompts.c]
+type="input"
+message="test"
+
+[prompts.a]
+type="input"
+message="test"
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c", "a"}, gen.PromptKeys())
+
+	keys := gen.PromptKeys()
+	keys[0] = "x"
+	require.Equal(t, []string{"b", "c", "a"}, gen.PromptKeys())
+}
+
+func TestPromptKeysWithStaleOrder(t *testing.T) {
+	gen := &Generator{promptOrder: []string{"b", "x"}, Prompts: PromptMap{
+		"b": &input{Base: Base{Msg: "test"}},
+		"a": &input{Base: Base{Msg: "test"}},
+	}}
+	require.Equal(t, []string{"a", "b"}, gen.PromptKeys())
+}
+
+func TestIsSecret(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"name":  &input{Base: Base{Msg: "test"}},
+		"token": &password{Base{Msg: "test"}},
+	}}
+	require.False(t, gen.IsSecret("name"))
+	require.True(t, gen.IsSecret("token"))
+	require.False(t, gen.IsSecret("unknown"))
+}
+
 func TestConfigReading(t *testing.T) {
 	for _, test := range configTests {
 		t.Run(test.name, func(t *testing.T) {
@@ -201,3 +571,129 @@ func TestConfigReading(t *testing.T) {
 		})
 	}
 }
+
+func TestAnswerAll(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"name": &input{Base: Base{Msg: "test"}},
+		"age":  &integer{Base: Base{Msg: "test"}},
+	}}
+
+	data, err := gen.AnswerAll(map[string]interface{}{"name": "John", "age": int64(30)})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "John", "age": 30}, data)
+}
+
+func TestAnswerAllWithDefaults(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"name": &input{Base: Base{Msg: "test", DefaultValue: "John"}},
+	}}
+
+	data, err := gen.AnswerAll(map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "John"}, data)
+}
+
+func TestPromptAllWithConditions(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"docker":   &confirm{Base{Msg: "test"}},
+		"registry": &input{Base: Base{Msg: "test", When: "vars['docker']", DefaultValue: "none"}},
+		"tag":      &input{Base: Base{Msg: "test", When: "vars['docker']"}},
+	}}
+
+	data, err := gen.PromptAll(&MockPrompter{"false"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"docker": false, "registry": "none"}, data)
+}
+
+func TestPromptMissing(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"name":    &input{Base: Base{Msg: "test"}},
+		"age":     &integer{Base: Base{Msg: "test"}},
+		"license": &input{Base: Base{Msg: "test", When: "vars['age'] > 18"}},
+	}}
+
+	data, err := gen.PromptMissing(&MockPrompter{"MIT"}, map[string]interface{}{"name": "John", "age": int64(30), "removed": "x"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "John", "age": 30, "license": "MIT"}, data)
+
+	_, err = gen.PromptMissing(&MockPrompter{"MIT"}, map[string]interface{}{"name": "John", "age": "abc"})
+	require.Error(t, err)
+}
+
+func TestAnswerAllWithConditions(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{
+		"docker":   &confirm{Base{Msg: "test"}},
+		"registry": &input{Base: Base{Msg: "test", When: "vars['docker']"}},
+	}}
+
+	data, err := gen.AnswerAll(map[string]interface{}{"docker": false, "registry": "test"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"docker": false}, data)
+
+	data, err = gen.AnswerAll(map[string]interface{}{"docker": true, "registry": "test"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"docker": true, "registry": "test"}, data)
+}
+
+func TestAnswerAllWithChainedConditions(t *testing.T) {
+	gen := &Generator{promptOrder: []string{"a", "b", "c"}, Prompts: PromptMap{
+		"a": &confirm{Base{Msg: "test"}},
+		"b": &input{Base: Base{Msg: "test", When: "vars['a']"}},
+		"c": &input{Base: Base{Msg: "test", When: "vars['b'] == 'y'"}},
+	}}
+
+	data, err := gen.AnswerAll(map[string]interface{}{"a": false})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": false}, data)
+
+	data, err = gen.AnswerAll(map[string]interface{}{"a": true, "b": "y", "c": "z"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": true, "b": "y", "c": "z"}, data)
+}
+
+func TestAnswerAllErrors(t *testing.T) {
+	gen := &Generator{Prompts: PromptMap{"age": &integer{Base: Base{Msg: "test"}, min: intPtr(0)}}}
+	tests := []struct {
+		name    string
+		answers map[string]interface{}
+		key     string
+	}{
+		{"missing answer", map[string]interface{}{}, "age"},
+		{"invalid answer", map[string]interface{}{"age": "abc"}, "age"},
+		{"unknown prompt", map[string]interface{}{"age": 1, "name": "John"}, "name"},
+		{"answer breaks validation rule", map[string]interface{}{"age": -1}, "age"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := gen.AnswerAll(test.answers)
+			require.Error(t, err)
+			require.IsType(t, &PromptError{}, err)
+			require.Equal(t, test.key, err.(*PromptError).Key)
+		})
+	}
+}
+
+func TestComputeVariables(t *testing.T) {
+	gen, err := ReadToml([]byte(`
+[variables]
+package = "vars['module'].split('/')[-1]"
+title = """
+name = vars['package']
+return name[0].upper() + name[1:]
+"""
+`))
+	require.NoError(t, err)
+
+	data := map[string]interface{}{"module": "github.com/g1ntas/accio"}
+	require.NoError(t, gen.ComputeVariables(data))
+	require.Equal(t, map[string]interface{}{
+		"module":  "github.com/g1ntas/accio",
+		"package": "accio",
+		"title":   "Accio",
+	}, data)
+}
+
+func TestComputeVariablesError(t *testing.T) {
+	gen := &Generator{Variables: VariableMap{"name": "vars['missing']"}}
+	require.Error(t, gen.ComputeVariables(map[string]interface{}{}))
+}
@@ -3,14 +3,29 @@ package manifest
 import (
 	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/g1ntas/accio/generator/blueprint"
 )
 
 type Prompter interface {
-	Get(message, help string, validator func(val string) error) (string, error)
-	SelectOne(message, help string, options []string) (string, error)
-	SelectMultiple(message, help string, options []string) ([]string, error)
-	Confirm(message, help string) (bool, error)
+	Get(message, help, defaultVal string, validator func(val string) error) (string, error)
+	SelectOne(message, help string, options []string, defaultVal string) (string, error)
+	SelectMultiple(message, help string, options []string, defaultVal []string, validator func(val []string) error) ([]string, error)
+	Confirm(message, help string, defaultVal bool) (bool, error)
+	Password(message, help string, validator func(val string) error) (string, error)
+	// GetPath prompts for a filesystem path, suggesting completions for
+	// paths relative to given directory.
+	GetPath(message, help, defaultVal, dir string, validator func(val string) error) (string, error)
+	// GetList prompts for multiple entries until an empty entry is submitted.
+	GetList(message, help string, defaultVal []string, validator func(val string) error) ([]string, error)
 }
 
 const (
@@ -19,19 +34,57 @@ const (
 	promptConfirm     = "confirm"
 	promptChoice      = "choice"
 	promptMultiChoice = "multi-choice"
+	promptPassword    = "password"
+	promptFloat       = "float"
+	promptPath        = "path"
+	promptList        = "list"
+	promptMap         = "map"
 )
 
-var nilValidator = func(val string) error {
-	return nil
-}
-
 var errNotInt = errors.New("value is not a valid integer")
 var errIntOutOfRange = errors.New("integer is too long")
+var errNotString = errors.New("value is not a string")
+var errNotBool = errors.New("value is not a valid boolean")
+var errNotList = errors.New("value is not a list")
+var errNotFloat = errors.New("value is not a valid number")
+var errNotMap = errors.New("value is not a map")
+var errNotKeyValue = errors.New("value must be in key=value format")
+var errPathNotExist = errors.New("path does not exist")
+
+// stat returns info of the file, it's a variable so it can be replaced in tests.
+var stat = os.Stat
 
 type Prompt interface {
 	kind() string
 	Help() string
-	Prompt(prompter Prompter) (interface{}, error)
+	// Default returns default value of the prompt or nil, if it's not set.
+	Default() interface{}
+	// Prompt prompts the user for the value. Given data holds values of
+	// already answered prompts, which are accessible in validation scripts.
+	Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error)
+	// parse converts given answer to the value of the same type,
+	// that would have been returned by the prompt.
+	parse(answer interface{}) (interface{}, error)
+	// validate checks whether value satisfies validation rules of the prompt.
+	validate(val interface{}, data map[string]interface{}) error
+	setDefault(val interface{})
+	// condition returns starlark expression, which determines
+	// whether the prompt should be asked.
+	condition() string
+}
+
+// PromptError records an error and the key of the prompt that caused it.
+type PromptError struct {
+	Key string
+	Err error
+}
+
+func (e *PromptError) Error() string {
+	return fmt.Sprintf("prompt %q: %s", e.Key, e.Err)
+}
+
+func (e *PromptError) Unwrap() error {
+	return e.Err
 }
 
 type PromptMap map[string]Prompt
@@ -45,50 +98,128 @@ func (m PromptMap) String() string {
 }
 
 type Base struct {
-	Msg      string
-	HelpText string
+	Msg          string
+	HelpText     string
+	DefaultValue interface{}
+	When         string
+	// ValidateScript is a starlark script, which returns an error
+	// message if the value is invalid.
+	ValidateScript string
 }
 
 func (p *Base) Help() string {
 	return p.HelpText
 }
 
+func (p *Base) Default() interface{} {
+	return p.DefaultValue
+}
+
+func (p *Base) setDefault(val interface{}) {
+	p.DefaultValue = val
+}
+
+func (p *Base) condition() string {
+	return p.When
+}
+
+// runValidateScript executes validation script, if it's specified, with the
+// value accessible through the `value` variable. Non-empty string returned
+// by the script is treated as an error message.
+func (p *Base) runValidateScript(val interface{}, data map[string]interface{}) error {
+	if p.ValidateScript == "" {
+		return nil
+	}
+	res, err := blueprint.EvalWith(p.ValidateScript, data, map[string]interface{}{"value": val})
+	if err != nil {
+		return fmt.Errorf("evaluating validation script: %w", err)
+	}
+	msg, ok := res.(string)
+	if !ok {
+		return fmt.Errorf("validation script must return a string, got %T", res)
+	}
+	if msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// ruleValidator is implemented by prompts, which have validation rules
+// that don't depend on data of other prompts and can be checked statically.
+type ruleValidator interface {
+	validateRules(val interface{}) error
+}
+
 type input struct {
 	Base
+	pattern   *regexp.Regexp
+	minLength int
+	maxLength int
 }
 
 func (p *input) kind() string {
 	return promptInput
 }
 
-func (p *input) Prompt(prompter Prompter) (interface{}, error) {
-	return prompter.Get(p.Msg, p.HelpText, nilValidator)
+func (p *input) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
+	def, _ := p.DefaultValue.(string)
+	return prompter.Get(p.Msg, p.HelpText, def, func(val string) error {
+		return p.validate(val, data)
+	})
+}
+
+func (p *input) validate(val interface{}, data map[string]interface{}) error {
+	if err := p.validateRules(val); err != nil {
+		return err
+	}
+	return p.runValidateScript(val, data)
+}
+
+func (p *input) validateRules(val interface{}) error {
+	s := val.(string)
+	if p.pattern != nil && !p.pattern.MatchString(s) {
+		return fmt.Errorf("value must match pattern %q", p.pattern)
+	}
+	l := utf8.RuneCountInString(s)
+	if p.minLength > 0 && l < p.minLength {
+		return fmt.Errorf("value must be at least %d characters long", p.minLength)
+	}
+	if p.maxLength > 0 && l > p.maxLength {
+		return fmt.Errorf("value must be at most %d characters long", p.maxLength)
+	}
+	return nil
+}
+
+func (p *input) parse(answer interface{}) (interface{}, error) {
+	s, ok := answer.(string)
+	if !ok {
+		return nil, errNotString
+	}
+	return s, nil
 }
 
 type integer struct {
 	Base
+	min *int
+	max *int
 }
 
 func (p *integer) kind() string {
 	return promptInteger
 }
 
-func (p *integer) Prompt(prompter Prompter) (interface{}, error) {
+func (p *integer) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
 	var value int
-	_, err := prompter.Get(p.Msg, p.HelpText, func(val string) error {
-		if len(val) == 0 {
-			return errNotInt
-		}
+	var def string
+	if i, ok := p.DefaultValue.(int); ok {
+		def = strconv.Itoa(i)
+	}
+	_, err := prompter.Get(p.Msg, p.HelpText, def, func(val string) error {
 		var err error
-		switch value, err = strconv.Atoi(val); {
-		case errors.Is(err, strconv.ErrSyntax):
-			return errNotInt
-		case errors.Is(err, strconv.ErrRange):
-			return errIntOutOfRange
-		case err != nil:
+		if value, err = parseInt(val); err != nil {
 			return err
 		}
-		return nil
+		return p.validate(value, data)
 	})
 	if err != nil {
 		return nil, err
@@ -96,6 +227,64 @@ func (p *integer) Prompt(prompter Prompter) (interface{}, error) {
 	return value, nil
 }
 
+func (p *integer) validate(val interface{}, data map[string]interface{}) error {
+	if err := p.validateRules(val); err != nil {
+		return err
+	}
+	return p.runValidateScript(val, data)
+}
+
+func (p *integer) validateRules(val interface{}) error {
+	i := val.(int)
+	if p.min != nil && i < *p.min {
+		return fmt.Errorf("value must be greater than or equal to %d", *p.min)
+	}
+	if p.max != nil && i > *p.max {
+		return fmt.Errorf("value must be less than or equal to %d", *p.max)
+	}
+	return nil
+}
+
+func (p *integer) parse(answer interface{}) (interface{}, error) {
+	switch v := answer.(type) {
+	case int:
+		return v, nil
+	case int64:
+		if int64(int(v)) != v {
+			return nil, errIntOutOfRange
+		}
+		return int(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, errNotInt
+		}
+		if v < math.MinInt64 || v >= math.MaxInt64 {
+			return nil, errIntOutOfRange
+		}
+		return p.parse(int64(v))
+	case string:
+		return parseInt(v)
+	}
+	return nil, errNotInt
+}
+
+// parseInt converts string into integer.
+func parseInt(val string) (int, error) {
+	if len(val) == 0 {
+		return 0, errNotInt
+	}
+	i, err := strconv.Atoi(val)
+	switch {
+	case errors.Is(err, strconv.ErrSyntax):
+		return 0, errNotInt
+	case errors.Is(err, strconv.ErrRange):
+		return 0, errIntOutOfRange
+	case err != nil:
+		return 0, err
+	}
+	return i, nil
+}
+
 type confirm struct {
 	Base
 }
@@ -104,32 +293,537 @@ func (p *confirm) kind() string {
 	return promptConfirm
 }
 
-func (p *confirm) Prompt(prompter Prompter) (interface{}, error) {
-	return prompter.Confirm(p.Msg, p.HelpText)
+func (p *confirm) Prompt(prompter Prompter, _ map[string]interface{}) (interface{}, error) {
+	def, _ := p.DefaultValue.(bool)
+	return prompter.Confirm(p.Msg, p.HelpText, def)
+}
+
+func (p *confirm) parse(answer interface{}) (interface{}, error) {
+	switch v := answer.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errNotBool
+		}
+		return b, nil
+	}
+	return nil, errNotBool
+}
+
+func (p *confirm) validate(_ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+// option represents a single selectable option of choice and multi-choice prompts.
+// The user sees the label, while the value is returned as an answer.
+type option struct {
+	label string
+	value interface{}
+	help  string
+}
+
+// options is a list of options for choice and multi-choice prompts.
+type options []option
+
+// labels returns labels of all options.
+func (opts options) labels() []string {
+	labels := make([]string, len(opts))
+	for i, o := range opts {
+		labels[i] = o.label
+	}
+	return labels
+}
+
+// find looks up the option by its value or, if no value matches, by its
+// label. String answers are also compared with string representation of
+// non-string values.
+func (opts options) find(answer interface{}) (option, error) {
+	if i, ok := answer.(int64); ok && int64(int(i)) == i {
+		answer = int(i)
+	}
+	if o, ok := opts.byValue(answer); ok {
+		return o, nil
+	}
+	if s, ok := answer.(string); ok {
+		for _, o := range opts {
+			if fmt.Sprint(o.value) == s {
+				return o, nil
+			}
+		}
+		if o, ok := opts.byLabel(s); ok {
+			return o, nil
+		}
+	}
+	return option{}, fmt.Errorf("value %v is not one of the options: %s", answer, strings.Join(opts.labels(), ", "))
+}
+
+// byLabel looks up the option by its label.
+func (opts options) byLabel(label string) (option, bool) {
+	for _, o := range opts {
+		if o.label == label {
+			return o, true
+		}
+	}
+	return option{}, false
+}
+
+// byLabels looks up options by given labels.
+func (opts options) byLabels(labels []string) ([]option, error) {
+	selected := make([]option, len(labels))
+	for i, l := range labels {
+		o, ok := opts.byLabel(l)
+		if !ok {
+			return nil, fmt.Errorf("unknown option %q selected", l)
+		}
+		selected[i] = o
+	}
+	return selected, nil
+}
+
+// byValue looks up the option by its value.
+func (opts options) byValue(val interface{}) (option, bool) {
+	for _, o := range opts {
+		if o.value == val {
+			return o, true
+		}
+	}
+	return option{}, false
+}
+
+// optionValues returns values of given options. If all values are strings,
+// then list of strings is returned, otherwise - list of interfaces.
+func optionValues(selected []option) interface{} {
+	strs := make([]string, len(selected))
+	vals := make([]interface{}, len(selected))
+	allStrings := true
+	for i, o := range selected {
+		vals[i] = o.value
+		strs[i], _ = o.value.(string)
+		if _, ok := o.value.(string); !ok {
+			allStrings = false
+		}
+	}
+	if allStrings {
+		return strs
+	}
+	return vals
+}
+
+// help appends help texts of the options to given help text.
+func (opts options) help(text string) string {
+	var lines []string
+	for _, o := range opts {
+		if o.help != "" {
+			lines = append(lines, fmt.Sprintf("%s - %s", o.label, o.help))
+		}
+	}
+	if len(lines) == 0 {
+		return text
+	}
+	if text != "" {
+		text += "\n\n"
+	}
+	return text + strings.Join(lines, "\n")
 }
 
 type choice struct {
 	Base
-	options []string
+	options options
 }
 
 func (p *choice) kind() string {
 	return promptChoice
 }
 
-func (p *choice) Prompt(prompter Prompter) (interface{}, error) {
-	return prompter.SelectOne(p.Msg, p.HelpText, p.options)
+func (p *choice) Prompt(prompter Prompter, _ map[string]interface{}) (interface{}, error) {
+	var def string
+	if o, ok := p.options.byValue(p.DefaultValue); ok {
+		def = o.label
+	}
+	label, err := prompter.SelectOne(p.Msg, p.options.help(p.HelpText), p.options.labels(), def)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := p.options.byLabel(label)
+	if !ok {
+		return nil, fmt.Errorf("unknown option %q selected", label)
+	}
+	return o.value, nil
+}
+
+func (p *choice) parse(answer interface{}) (interface{}, error) {
+	o, err := p.options.find(answer)
+	if err != nil {
+		return nil, err
+	}
+	return o.value, nil
+}
+
+func (p *choice) validate(_ interface{}, _ map[string]interface{}) error {
+	return nil
 }
 
 type multiChoice struct {
 	Base
-	options []string
+	options     options
+	minSelected int
+	maxSelected int
 }
 
 func (p *multiChoice) kind() string {
 	return promptMultiChoice
 }
 
-func (p *multiChoice) Prompt(prompter Prompter) (interface{}, error) {
-	return prompter.SelectMultiple(p.Msg, p.HelpText, p.options)
+func (p *multiChoice) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
+	var def []string
+	for _, v := range listOf(p.DefaultValue) {
+		if o, ok := p.options.byValue(v); ok {
+			def = append(def, o.label)
+		}
+	}
+	labels, err := prompter.SelectMultiple(p.Msg, p.options.help(p.HelpText), p.options.labels(), def, func(labels []string) error {
+		selected, err := p.options.byLabels(labels)
+		if err != nil {
+			return err
+		}
+		return p.validate(optionValues(selected), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	selected, err := p.options.byLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	return optionValues(selected), nil
+}
+
+func (p *multiChoice) validate(val interface{}, data map[string]interface{}) error {
+	if err := p.validateRules(val); err != nil {
+		return err
+	}
+	return p.runValidateScript(val, data)
+}
+
+func (p *multiChoice) validateRules(val interface{}) error {
+	n := len(listOf(val))
+	if p.minSelected > 0 && n < p.minSelected {
+		return fmt.Errorf("at least %d options must be selected", p.minSelected)
+	}
+	if p.maxSelected > 0 && n > p.maxSelected {
+		return fmt.Errorf("at most %d options can be selected", p.maxSelected)
+	}
+	return nil
+}
+
+// parse accepts a list of options or a string of comma-separated options.
+func (p *multiChoice) parse(answer interface{}) (interface{}, error) {
+	var list []interface{}
+	if s, ok := answer.(string); ok {
+		strs, _ := parseStringList(s)
+		list = listOf(strs)
+	} else if list = listOf(answer); list == nil {
+		return nil, errNotList
+	}
+	selected := make([]option, len(list))
+	for i, v := range list {
+		o, err := p.options.find(v)
+		if err != nil {
+			return nil, err
+		}
+		selected[i] = o
+	}
+	return optionValues(selected), nil
+}
+
+// listOf converts list of strings or interfaces into list of interfaces.
+// Nil is returned, if value is not a list.
+func listOf(v interface{}) []interface{} {
+	switch l := v.(type) {
+	case []interface{}:
+		return l
+	case []string:
+		list := make([]interface{}, len(l))
+		for i, s := range l {
+			list[i] = s
+		}
+		return list
+	}
+	return nil
+}
+
+// parseStringList converts a list of strings or a string of comma-separated values into go slice.
+func parseStringList(answer interface{}) ([]string, error) {
+	switch v := answer.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, el := range v {
+			s, ok := el.(string)
+			if !ok {
+				return nil, errNotString
+			}
+			list[i] = s
+		}
+		return list, nil
+	case string:
+		list := []string{}
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list, nil
+	}
+	return nil, errNotList
+}
+
+type password struct {
+	Base
+}
+
+func (p *password) kind() string {
+	return promptPassword
+}
+
+func (p *password) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
+	return prompter.Password(p.Msg, p.HelpText, func(val string) error {
+		return p.validate(val, data)
+	})
+}
+
+func (p *password) parse(answer interface{}) (interface{}, error) {
+	s, ok := answer.(string)
+	if !ok {
+		return nil, errNotString
+	}
+	return s, nil
+}
+
+func (p *password) validate(val interface{}, data map[string]interface{}) error {
+	return p.runValidateScript(val, data)
+}
+
+type float struct {
+	Base
+}
+
+func (p *float) kind() string {
+	return promptFloat
+}
+
+func (p *float) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
+	var value float64
+	var def string
+	if f, ok := p.DefaultValue.(float64); ok {
+		def = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	_, err := prompter.Get(p.Msg, p.HelpText, def, func(val string) error {
+		var err error
+		if value, err = parseFloat(val); err != nil {
+			return err
+		}
+		return p.validate(value, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *float) parse(answer interface{}) (interface{}, error) {
+	switch v := answer.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return parseFloat(v)
+	}
+	return nil, errNotFloat
+}
+
+func (p *float) validate(val interface{}, data map[string]interface{}) error {
+	return p.runValidateScript(val, data)
+}
+
+// parseFloat converts string into finite floating-point number.
+func parseFloat(val string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+type path struct {
+	Base
+	mustExist bool
+	// dir is the directory relative paths are resolved against,
+	// or empty to resolve them against the current working directory.
+	dir string
+}
+
+func (p *path) kind() string {
+	return promptPath
+}
+
+func (p *path) Prompt(prompter Prompter, data map[string]interface{}) (interface{}, error) {
+	def, _ := p.DefaultValue.(string)
+	val, err := prompter.GetPath(p.Msg, p.HelpText, def, p.dir, func(val string) error {
+		return p.validate(cleanPath(val), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cleanPath(val), nil
+}
+
+func (p *path) parse(answer interface{}) (interface{}, error) {
+	s, ok := answer.(string)
+	if !ok {
+		return nil, errNotString
+	}
+	return cleanPath(s), nil
+}
+
+func (p *path) validate(val interface{}, data map[string]interface{}) error {
+	s := val.(string)
+	if s == "" {
+		return errors.New("path must not be empty")
+	}
+	if p.mustExist {
+		if _, err := stat(p.resolve(s)); os.IsNotExist(err) {
+			return errPathNotExist
+		} else if err != nil {
+			return err
+		}
+	}
+	return p.runValidateScript(val, data)
+}
+
+// resolve converts given Unix-like path into the path of the operating
+// system, joining relative paths with the directory of the prompt.
+func (p *path) resolve(s string) string {
+	s = filepath.FromSlash(s)
+	if filepath.IsAbs(s) {
+		return s
+	}
+	return filepath.Join(p.dir, s)
+}
+
+// cleanPath returns the shortest Unix-like path equivalent to given path.
+func cleanPath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return p
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+type list struct {
+	Base
+}
+
+func (p *list) kind() string {
+	return promptList
+}
+
+func (p *list) Prompt(prompter Prompter, _ map[string]interface{}) (interface{}, error) {
+	def, _ := p.DefaultValue.([]string)
+	return prompter.GetList(p.Msg, p.HelpText, def, func(val string) error {
+		return nil
+	})
+}
+
+// parse accepts a list of strings or a string of comma-separated values.
+func (p *list) parse(answer interface{}) (interface{}, error) {
+	return parseStringList(answer)
+}
+
+func (p *list) validate(_ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+type keyValueMap struct {
+	Base
+}
+
+func (p *keyValueMap) kind() string {
+	return promptMap
+}
+
+func (p *keyValueMap) Prompt(prompter Prompter, _ map[string]interface{}) (interface{}, error) {
+	var def []string
+	if m, ok := p.DefaultValue.(map[string]string); ok {
+		for _, k := range sortedStringKeys(m) {
+			def = append(def, k+"="+m[k])
+		}
+	}
+	entries, err := prompter.GetList(p.Msg, p.HelpText, def, func(val string) error {
+		_, _, err := parseKeyValue(val)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.parse(entries)
+}
+
+// parse accepts a map of strings, a list of entries in key=value format,
+// or a string of comma-separated entries in key=value format.
+func (p *keyValueMap) parse(answer interface{}) (interface{}, error) {
+	m := make(map[string]string)
+	if v, ok := answer.(map[string]interface{}); ok {
+		for k, el := range v {
+			s, ok := el.(string)
+			if !ok {
+				return nil, errNotString
+			}
+			m[k] = s
+		}
+		return m, nil
+	}
+	if v, ok := answer.(map[string]string); ok {
+		return v, nil
+	}
+	entries, err := parseStringList(answer)
+	if err != nil {
+		return nil, errNotMap
+	}
+	for _, e := range entries {
+		k, v, err := parseKeyValue(e)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (p *keyValueMap) validate(_ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+// parseKeyValue splits entry in key=value format into key and value.
+func parseKeyValue(entry string) (string, string, error) {
+	kv := strings.SplitN(entry, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return "", "", errNotKeyValue
+	}
+	return strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]), nil
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -2,6 +2,9 @@ package manifest
 
 import (
 	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -13,7 +16,7 @@ type MockPrompter struct {
 
 var _ Prompter = (*MockPrompter)(nil)
 
-func (p *MockPrompter) Get(_, _ string, validator func(val string) error) (string, error) {
+func (p *MockPrompter) Get(_, _, _ string, validator func(val string) error) (string, error) {
 	err := validator(p.input)
 	if err != nil {
 		return "", err
@@ -21,15 +24,39 @@ func (p *MockPrompter) Get(_, _ string, validator func(val string) error) (strin
 	return p.input, nil
 }
 
-func (p *MockPrompter) SelectOne(_, _ string, _ []string) (string, error) {
+func (p *MockPrompter) SelectOne(_, _ string, _ []string, _ string) (string, error) {
 	return p.input, nil
 }
 
-func (p *MockPrompter) SelectMultiple(_, _ string, _ []string) ([]string, error) {
-	return strings.Split(p.input, "\n"), nil
+func (p *MockPrompter) SelectMultiple(_, _ string, _ []string, _ []string, validator func(val []string) error) ([]string, error) {
+	val := strings.Split(p.input, "\n")
+	err := validator(val)
+	if err != nil {
+		return []string{}, err
+	}
+	return val, nil
+}
+
+func (p *MockPrompter) Password(_, _ string, validator func(val string) error) (string, error) {
+	return p.Get("", "", "", validator)
+}
+
+func (p *MockPrompter) GetPath(_, _, _, _ string, validator func(val string) error) (string, error) {
+	return p.Get("", "", "", validator)
 }
 
-func (p *MockPrompter) Confirm(_, _ string) (bool, error) {
+func (p *MockPrompter) GetList(_, _ string, _ []string, validator func(val string) error) ([]string, error) {
+	val := strings.Split(p.input, "\n")
+	for _, v := range val {
+		err := validator(v)
+		if err != nil {
+			return []string{}, err
+		}
+	}
+	return val, nil
+}
+
+func (p *MockPrompter) Confirm(_, _ string, _ bool) (bool, error) {
 	return strconv.ParseBool(p.input)
 }
 
@@ -53,7 +80,7 @@ func TestInteger(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			prompter := &MockPrompter{test.input}
 			prompt := new(integer)
-			val, err := prompt.Prompt(prompter)
+			val, err := prompt.Prompt(prompter, nil)
 			if _, isErr := test.expected.(error); isErr {
 				require.Equal(t, test.expected, err)
 			} else {
@@ -63,3 +90,172 @@ func TestInteger(t *testing.T) {
 		})
 	}
 }
+
+// stringOptions creates options with values same as labels.
+func stringOptions(labels ...string) options {
+	opts := make(options, len(labels))
+	for i, l := range labels {
+		opts[i] = option{label: l, value: l}
+	}
+	return opts
+}
+
+var labeledOptions = options{
+	{label: "MIT License", value: "mit"},
+	{label: "Version 2", value: 2},
+}
+
+var answerTests = []struct {
+	name     string
+	prompt   Prompt
+	answer   interface{}
+	expected interface{}
+	ok       bool
+}{
+	{"input string", &input{}, "test", "test", noError},
+	{"input non-string", &input{}, 1, nil, hasError},
+	{"integer int", &integer{}, 1, 1, noError},
+	{"integer int64", &integer{}, int64(-1), -1, noError},
+	{"integer float", &integer{}, float64(2), 2, noError},
+	{"integer fractional float", &integer{}, 2.5, nil, hasError},
+	{"integer string", &integer{}, "+3", 3, noError},
+	{"integer invalid string", &integer{}, "3a", nil, hasError},
+	{"integer bool", &integer{}, true, nil, hasError},
+	{"confirm bool", &confirm{}, true, true, noError},
+	{"confirm string", &confirm{}, "false", false, noError},
+	{"confirm invalid string", &confirm{}, "maybe", nil, hasError},
+	{"choice option", &choice{options: stringOptions("a", "b")}, "b", "b", noError},
+	{"choice unknown option", &choice{options: stringOptions("a", "b")}, "c", nil, hasError},
+	{"choice non-string", &choice{options: stringOptions("a", "b")}, 1, nil, hasError},
+	{"multi-choice list", &multiChoice{options: stringOptions("a", "b")}, []interface{}{"a", "b"}, []string{"a", "b"}, noError},
+	{"multi-choice empty list", &multiChoice{options: stringOptions("a", "b")}, []interface{}{}, []string{}, noError},
+	{"multi-choice comma-separated", &multiChoice{options: stringOptions("a", "b")}, "b, a", []string{"b", "a"}, noError},
+	{"multi-choice unknown option", &multiChoice{options: stringOptions("a", "b")}, []interface{}{"a", "c"}, nil, hasError},
+	{"multi-choice non-list", &multiChoice{options: stringOptions("a", "b")}, 1, nil, hasError},
+	{"choice value of labeled option", &choice{options: labeledOptions}, "mit", "mit", noError},
+	{"choice label of labeled option", &choice{options: labeledOptions}, "MIT License", "mit", noError},
+	{"choice integer value", &choice{options: labeledOptions}, int64(2), 2, noError},
+	{"choice integer value as string", &choice{options: labeledOptions}, "2", 2, noError},
+	{"multi-choice labeled options", &multiChoice{options: labeledOptions}, []interface{}{"mit", int64(2)}, []interface{}{"mit", 2}, noError},
+	{"multi-choice labeled string options", &multiChoice{options: labeledOptions}, "MIT License", []string{"mit"}, noError},
+	{"choice value preferred over label", &choice{options: options{{label: "mit", value: "x"}, {label: "MIT", value: "mit"}}}, "mit", "mit", noError},
+	{"password string", &password{}, "secret", "secret", noError},
+	{"float", &float{}, 1.5, 1.5, noError},
+	{"float int", &float{}, int64(2), float64(2), noError},
+	{"float string", &float{}, "-0.5", -0.5, noError},
+	{"float invalid string", &float{}, "NaN", nil, hasError},
+	{"path", &path{}, "./a//b/../c", "a/c", noError},
+	{"path non-string", &path{}, 1, nil, hasError},
+	{"list", &list{}, []interface{}{"a", "b"}, []string{"a", "b"}, noError},
+	{"list comma-separated", &list{}, "a, b", []string{"a", "b"}, noError},
+	{"list non-string element", &list{}, []interface{}{1}, nil, hasError},
+	{"map", &keyValueMap{}, map[string]interface{}{"a": "1"}, map[string]string{"a": "1"}, noError},
+	{"map entries", &keyValueMap{}, []interface{}{"a=1", "b = 2"}, map[string]string{"a": "1", "b": "2"}, noError},
+	{"map comma-separated entries", &keyValueMap{}, "a=1,b=2", map[string]string{"a": "1", "b": "2"}, noError},
+	{"map invalid entry", &keyValueMap{}, "a", nil, hasError},
+	{"map non-string value", &keyValueMap{}, map[string]interface{}{"a": 1}, nil, hasError},
+}
+
+func TestAnswerParsing(t *testing.T) {
+	for _, test := range answerTests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := test.prompt.parse(test.answer)
+			if !test.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, val)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+var validationTests = []struct {
+	name   string
+	prompt Prompt
+	input  string
+	ok     bool
+}{
+	{"input pattern match", &input{pattern: regexp.MustCompile("^[a-z]+$")}, "abc", noError},
+	{"input pattern mismatch", &input{pattern: regexp.MustCompile("^[a-z]+$")}, "ab1", hasError},
+	{"input min length", &input{minLength: 3}, "ąčę", noError},
+	{"input too short", &input{minLength: 3}, "ab", hasError},
+	{"input max length", &input{maxLength: 3}, "abc", noError},
+	{"input too long", &input{maxLength: 3}, "abcd", hasError},
+	{"input validate script", &input{Base: Base{ValidateScript: `"" if value.islower() else "must be lowercase"`}}, "abc", noError},
+	{"input validate script error", &input{Base: Base{ValidateScript: `"" if value.islower() else "must be lowercase"`}}, "ABC", hasError},
+	{"input validate script returns none", &input{Base: Base{ValidateScript: `None`}}, "abc", noError},
+	{"input validate script returns non-string", &input{Base: Base{ValidateScript: `1`}}, "abc", hasError},
+	{"integer min", &integer{min: intPtr(0)}, "0", noError},
+	{"integer less than min", &integer{min: intPtr(0)}, "-1", hasError},
+	{"integer max", &integer{max: intPtr(10)}, "10", noError},
+	{"integer greater than max", &integer{max: intPtr(10)}, "11", hasError},
+	{"integer validate script", &integer{Base: Base{ValidateScript: `"" if value % 2 == 0 else "must be even"`}}, "3", hasError},
+	{"multi-choice min selected", &multiChoice{options: stringOptions("a", "b"), minSelected: 2}, "a\nb", noError},
+	{"multi-choice too few selected", &multiChoice{options: stringOptions("a", "b"), minSelected: 2}, "a", hasError},
+	{"multi-choice max selected", &multiChoice{options: stringOptions("a", "b"), maxSelected: 1}, "a", noError},
+	{"multi-choice too many selected", &multiChoice{options: stringOptions("a", "b"), maxSelected: 1}, "a\nb", hasError},
+	{"password validate script", &password{Base{ValidateScript: `"" if len(value) >= 8 else "too short"`}}, "secret", hasError},
+	{"float", &float{}, "1.5", noError},
+	{"invalid float", &float{}, "abc", hasError},
+	{"path", &path{}, "abc", noError},
+	{"empty path", &path{}, "", hasError},
+	{"existing path", &path{mustExist: true}, "exists", noError},
+	{"non-existing path", &path{mustExist: true}, "missing", hasError},
+	{"existing path in directory", &path{mustExist: true, dir: "dir"}, "exists", noError},
+	{"absolute path in directory", &path{mustExist: true, dir: "dir"}, "/exists", noError},
+	{"path existing only outside directory", &path{mustExist: true, dir: "other"}, "exists", hasError},
+	{"map entries", &keyValueMap{}, "a=1\nb=2", noError},
+	{"map invalid entry", &keyValueMap{}, "a", hasError},
+}
+
+func TestValidation(t *testing.T) {
+	stat = func(name string) (os.FileInfo, error) {
+		switch filepath.ToSlash(name) {
+		case "exists", "dir/exists", "/exists":
+			return nil, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	defer func() { stat = os.Stat }()
+	for _, test := range validationTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.prompt.Prompt(&MockPrompter{test.input}, map[string]interface{}{})
+			if test.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestChoiceReturnsValueOfSelectedLabel(t *testing.T) {
+	val, err := (&choice{options: labeledOptions}).Prompt(&MockPrompter{"Version 2"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, val)
+
+	val, err = (&multiChoice{options: labeledOptions}).Prompt(&MockPrompter{"MIT License\nVersion 2"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"mit", 2}, val)
+}
+
+// selectingPrompter selects given labels without calling the validator.
+type selectingPrompter struct {
+	MockPrompter
+	labels []string
+}
+
+func (p *selectingPrompter) SelectMultiple(_, _ string, _ []string, _ []string, _ func(val []string) error) ([]string, error) {
+	return p.labels, nil
+}
+
+func TestMultiChoiceReturnsValuesOfSelectedLabels(t *testing.T) {
+	val, err := (&multiChoice{options: labeledOptions}).Prompt(&selectingPrompter{labels: []string{"MIT License"}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mit"}, val)
+}
@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"strings"
+)
+
+// promptTypes lists types of prompts in the order they are offered when scaffolding.
+var promptTypes = []string{
+	promptInput,
+	promptInteger,
+	promptFloat,
+	promptConfirm,
+	promptChoice,
+	promptMultiChoice,
+	promptPassword,
+	promptPath,
+	promptList,
+	promptMap,
+}
+
+// scaffoldPrompt is a definition of the prompt written by Scaffold.
+type scaffoldPrompt struct {
+	Type    string   `toml:"type"`
+	Message string   `toml:"message"`
+	Help    string   `toml:"help,omitempty"`
+	Options []string `toml:"options,omitempty"`
+}
+
+// Scaffold interactively builds the manifest of a new generator by asking
+// for its help text, prompts and ignored paths, and returns it in TOML format.
+func Scaffold(prompter Prompter) ([]byte, error) {
+	var g struct {
+		Help   string   `toml:"help,omitempty"`
+		Ignore []string `toml:"ignore,omitempty"`
+	}
+	var err error
+	g.Help, err = prompter.Get("Describe the generator:", "Shown by the help of the generator.", "", noValidation)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	prompts := make(map[string]scaffoldPrompt)
+	for {
+		add, err := prompter.Confirm("Add a prompt?", "Prompts ask for data used by templates.", len(keys) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if !add {
+			break
+		}
+		key, pr, err := scaffoldPromptDef(prompter, prompts)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		prompts[key] = pr
+	}
+	g.Ignore, err = prompter.GetList("Path to ignore:", "Paths relative to the root of the generator, which are not generated.", nil, noValidation)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err = toml.NewEncoder(buf).Encode(g); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		fmt.Fprintf(buf, "\n[prompts.%s]\n", k)
+		if err = toml.NewEncoder(buf).Encode(prompts[k]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// scaffoldPromptDef asks for the key and options of a single prompt.
+func scaffoldPromptDef(prompter Prompter, prompts map[string]scaffoldPrompt) (string, scaffoldPrompt, error) {
+	var pr scaffoldPrompt
+	key, err := prompter.Get("Prompt key:", "Name of the variable holding the answer.", "", func(k string) error {
+		if k == "" {
+			return errors.New("prompt key must not be empty")
+		}
+		if _, ok := prompts[k]; ok {
+			return fmt.Errorf("prompt %q is already defined", k)
+		}
+		return validateKey("prompt", k)
+	})
+	if err != nil {
+		return "", pr, err
+	}
+	if pr.Type, err = prompter.SelectOne("Prompt type:", "", promptTypes, promptInput); err != nil {
+		return "", pr, err
+	}
+	pr.Message, err = prompter.Get("Prompt message:", "", "", func(msg string) error {
+		_, err := parsePromptMessage(map[string]interface{}{"message": msg}, key)
+		return err
+	})
+	if err != nil {
+		return "", pr, err
+	}
+	if pr.Help, err = prompter.Get("Prompt help:", "Optional description of the prompt.", "", noValidation); err != nil {
+		return "", pr, err
+	}
+	if pr.Type == promptChoice || pr.Type == promptMultiChoice {
+		pr.Options, err = prompter.GetList("Option:", "Options to choose from.", nil, func(o string) error {
+			if strings.TrimSpace(o) == "" {
+				return errors.New("option must not be blank")
+			}
+			return nil
+		})
+		if err != nil {
+			return "", pr, err
+		}
+		if len(pr.Options) == 0 {
+			return "", pr, fmt.Errorf("prompt %q of type %q requires at least one option", key, pr.Type)
+		}
+	}
+	return key, pr, nil
+}
+
+func noValidation(_ string) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+// scriptedPrompter answers prompts with given answers in order.
+type scriptedPrompter struct {
+	answers []string
+}
+
+func (p *scriptedPrompter) next() string {
+	a := p.answers[0]
+	p.answers = p.answers[1:]
+	return a
+}
+
+func (p *scriptedPrompter) Get(_, _, _ string, validator func(val string) error) (string, error) {
+	val := p.next()
+	return val, validator(val)
+}
+
+func (p *scriptedPrompter) SelectOne(_, _ string, _ []string, _ string) (string, error) {
+	return p.next(), nil
+}
+
+func (p *scriptedPrompter) SelectMultiple(_, _ string, _ []string, _ []string, _ func(val []string) error) ([]string, error) {
+	return []string{p.next()}, nil
+}
+
+func (p *scriptedPrompter) Confirm(_, _ string, _ bool) (bool, error) {
+	return strconv.ParseBool(p.next())
+}
+
+func (p *scriptedPrompter) Password(_, _ string, validator func(val string) error) (string, error) {
+	return p.Get("", "", "", validator)
+}
+
+func (p *scriptedPrompter) GetPath(_, _, _, _ string, validator func(val string) error) (string, error) {
+	return p.Get("", "", "", validator)
+}
+
+// GetList takes answers until an empty answer.
+func (p *scriptedPrompter) GetList(_, _ string, _ []string, validator func(val string) error) ([]string, error) {
+	var list []string
+	for val := p.next(); val != ""; val = p.next() {
+		if err := validator(val); err != nil {
+			return nil, err
+		}
+		list = append(list, val)
+	}
+	return list, nil
+}
+
+func TestScaffold(t *testing.T) {
+	prompter := &scriptedPrompter{answers: []string{
+		"Generates a service",
+		"true", "name", "input", "Service name:", "",
+		"true", "db", "choice", "Database:", "Used for storage", "mysql", "postgres", "",
+		"false",
+		"README.md", "",
+	}}
+	b, err := Scaffold(prompter)
+	require.NoError(t, err)
+	require.Equal(t, `help = "Generates a service"
+ignore = ["README.md"]
+
+[prompts.name]
+type = "input"
+message = "Service name:"
+
+[prompts.db]
+type = "choice"
+message = "Database:"
+help = "Used for storage"
+options = ["mysql", "postgres"]
+`, string(b))
+	g, err := ReadToml(b)
+	require.NoError(t, err)
+	require.Equal(t, []string{"name", "db"}, g.PromptKeys())
+}
+
+func TestScaffoldErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+	}{
+		{"invalid key", []string{"", "true", "1name"}},
+		{"empty message", []string{"", "true", "name", "input", ""}},
+		{"choice without options", []string{"", "true", "name", "choice", "Choose:", "", ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Scaffold(&scriptedPrompter{answers: test.answers})
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package setup prepares the blueprint parser and the runner of a generator,
+// so generators are run the same way by the CLI and by the accio package.
+package setup
+
+import (
+	"fmt"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/manifest"
+)
+
+// ManifestFilename is the name of the manifest file at the root of the generator.
+const ManifestFilename = ".accio.toml"
+
+// ReadManifest reads manifest of the generator within given file tree.
+func ReadManifest(r generator.FileTreeReader) (*manifest.Generator, error) {
+	b, err := r.ReadFile(ManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	gen, err := manifest.ReadToml(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	return gen, nil
+}
+
+// ParserOptions returns options of the blueprint parser for the generator within given file tree.
+func ParserOptions(r generator.FileTreeReader, strict bool) []blueprint.OptionFn {
+	options := []blueprint.OptionFn{blueprint.WithFileReader(r)}
+	if strict {
+		options = append(options, blueprint.Strict)
+	}
+	return options
+}
+
+// RunnerOptions returns options of the runner shared by all runs of the generator,
+// which render names of files with given parser and ignore the manifest, git
+// directory and paths from the ignore list of the manifest.
+func RunnerOptions(gen *manifest.Generator, parser *blueprint.Parser, log generator.Logger) []generator.OptionFn {
+	options := []generator.OptionFn{
+		generator.WithLogger(log),
+		generator.IgnorePath(".git"),
+		generator.IgnorePath(ManifestFilename),
+		generator.RenderNames(parser),
+	}
+	for _, p := range gen.Ignore {
+		options = append(options, generator.IgnorePath(p))
+	}
+	return options
+}
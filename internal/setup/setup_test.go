@@ -0,0 +1,58 @@
+package setup
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+
+	"github.com/g1ntas/accio/generator"
+	"github.com/g1ntas/accio/generator/blueprint"
+	"github.com/g1ntas/accio/internal/fs"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(...interface{}) {}
+func (nopLogger) Info(...interface{})  {}
+
+func TestReadManifest(t *testing.T) {
+	base := afero.NewMemMapFs()
+	tree := fs.NewAferoFileTreeReader(base, "/generator")
+	_, err := ReadManifest(tree)
+	require.Error(t, err)
+
+	require.NoError(t, afero.WriteFile(base, "/generator/"+ManifestFilename, []byte("ignore = ["), 0644))
+	_, err = ReadManifest(tree)
+	require.Error(t, err)
+
+	require.NoError(t, afero.WriteFile(base, "/generator/"+ManifestFilename, []byte(`ignore = ["tests"]`), 0644))
+	gen, err := ReadManifest(tree)
+	require.NoError(t, err)
+	require.Equal(t, []string{"tests"}, gen.Ignore)
+}
+
+func TestRunnerOptions(t *testing.T) {
+	base := afero.NewMemMapFs()
+	files := []string{ManifestFilename, ".git/HEAD", "tests/a.txt", "{{name}}.txt", "b.txt"}
+	for _, f := range files {
+		require.NoError(t, afero.WriteFile(base, "/generator/"+f, []byte("ignore = [\"tests\"]"), 0644))
+	}
+	tree := fs.NewAferoFileTreeReader(base, "/generator")
+	gen, err := ReadManifest(tree)
+	require.NoError(t, err)
+	parser, err := blueprint.NewParser(map[string]interface{}{"name": "a"}, nopLogger{}, ParserOptions(tree, true)...)
+	require.NoError(t, err)
+
+	out := afero.Afero{Fs: afero.NewMemMapFs()}
+	err = generator.NewRunner(out, parser, "/output", RunnerOptions(gen, parser, nopLogger{})...).Run(tree)
+	require.NoError(t, err)
+	var written []string
+	require.NoError(t, out.Walk("/output", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			written = append(written, path)
+		}
+		return err
+	}))
+	require.Equal(t, []string{"/output/a.txt", "/output/b.txt"}, written)
+}
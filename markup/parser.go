@@ -32,6 +32,16 @@ type AttrNode struct {
 	Value string
 }
 
+// Error is a syntax error of the document.
+type Error struct {
+	Msg  string
+	Line int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at line %d", e.Msg, e.Line)
+}
+
 // Pos represents a byte position in the original input text from which
 // this template was parsed.
 type Pos int
@@ -174,7 +184,7 @@ func parseDelimiterAttrs(p *Parser) parseStateFn {
 			return p.errorf("attribute '%s' is already defined", name)
 		}
 		if containsInvisibleChars(value) {
-			return p.errorf("attribute '%s' must not contain any invisible character")
+			return p.errorf("attribute '%s' must not contain any invisible character", name)
 		}
 		attrs[name] = value
 	}
@@ -252,8 +262,7 @@ func (p *Parser) next() token {
 
 // errorf formats the error and terminates processing.
 func (p *Parser) errorf(format string, args ...interface{}) parseStateFn {
-	format = fmt.Sprintf("%s at line %d", format, p.token.line)
-	panic(fmt.Errorf(format, args...))
+	panic(&Error{fmt.Sprintf(format, args...), p.token.line})
 }
 
 // error terminates processing with error.
@@ -1,9 +1,14 @@
 package prompter
 
 import (
+	"fmt"
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
 	"github.com/AlecAivazis/survey/v2/terminal"
 	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 )
 
 type CLI struct {
@@ -21,10 +26,11 @@ func setDefaultStyle(icons *survey.IconSet) {
 	icons.Error.Format = ""
 }
 
-func (p *CLI) Get(message, help string, validate func(val string) error) (val string, err error) {
+func (p *CLI) Get(message, help, defaultVal string, validate func(val string) error) (val string, err error) {
 	prompt := &survey.Input{
 		Message: message,
 		Help:    help,
+		Default: defaultVal,
 	}
 	validator := func(answer interface{}) error {
 		if err := validate(answer.(string)); err != nil {
@@ -43,10 +49,118 @@ func (p *CLI) Get(message, help string, validate func(val string) error) (val st
 	return
 }
 
-func (p *CLI) Confirm(message, help string) (val bool, err error) {
+// GetPath prompts for a filesystem path and suggests completions of the
+// entered path on Tab key. Relative paths are completed within given
+// directory, or current working directory, if it's empty.
+func (p *CLI) GetPath(message, help, defaultVal, dir string, validate func(val string) error) (val string, err error) {
+	prompt := &survey.Input{
+		Message: message,
+		Help:    help,
+		Default: defaultVal,
+		Suggest: func(toComplete string) []string {
+			return suggestPaths(dir, toComplete)
+		},
+	}
+	validator := func(answer interface{}) error {
+		if err := validate(answer.(string)); err != nil {
+			return err
+		}
+		return nil
+	}
+	err = survey.AskOne(prompt, &val,
+		survey.WithValidator(validator),
+		survey.WithIcons(setDefaultStyle),
+		survey.WithStdio(p.Stdin, p.Stdout, p.Stderr),
+	)
+	if err != nil {
+		return "", err
+	}
+	return
+}
+
+// suggestPaths returns paths of files and directories starting with given
+// path. Directories are suffixed with a slash, so they can be completed further.
+func suggestPaths(dir, toComplete string) []string {
+	parent, prefix := filepath.Split(filepath.FromSlash(toComplete))
+	lookup := parent
+	if !filepath.IsAbs(lookup) {
+		lookup = filepath.Join(dir, lookup)
+	}
+	entries, err := ioutil.ReadDir(lookup)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		path := filepath.ToSlash(filepath.Join(parent, e.Name()))
+		if e.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// GetList prompts for entries one by one, until an empty entry is submitted.
+// If no entries were submitted, then default value is returned.
+func (p *CLI) GetList(message, help string, defaultVal []string, validate func(val string) error) ([]string, error) {
+	validator := func(val string) error {
+		if val == "" {
+			return nil
+		}
+		return validate(val)
+	}
+	list := []string{}
+	for {
+		msg := fmt.Sprintf("%s (entry #%d, leave empty to finish)", message, len(list)+1)
+		if len(list) == 0 && len(defaultVal) > 0 {
+			msg += fmt.Sprintf(" [default: %s]", strings.Join(defaultVal, ", "))
+		}
+		val, err := p.Get(msg, help, "", validator)
+		if err != nil {
+			return []string{}, err
+		}
+		if val == "" {
+			break
+		}
+		list = append(list, val)
+	}
+	if len(list) == 0 && len(defaultVal) > 0 {
+		return defaultVal, nil
+	}
+	return list, nil
+}
+
+func (p *CLI) Password(message, help string, validate func(val string) error) (val string, err error) {
+	prompt := &survey.Password{
+		Message: message,
+		Help:    help,
+	}
+	validator := func(answer interface{}) error {
+		if err := validate(answer.(string)); err != nil {
+			return err
+		}
+		return nil
+	}
+	err = survey.AskOne(prompt, &val,
+		survey.WithValidator(validator),
+		survey.WithIcons(setDefaultStyle),
+		survey.WithStdio(p.Stdin, p.Stdout, p.Stderr),
+	)
+	if err != nil {
+		return "", err
+	}
+	return
+}
+
+func (p *CLI) Confirm(message, help string, defaultVal bool) (val bool, err error) {
 	prompt := &survey.Confirm{
 		Message: message,
 		Help:    help,
+		Default: defaultVal,
 	}
 	err = survey.AskOne(prompt, &val,
 		survey.WithIcons(setDefaultStyle),
@@ -58,12 +172,16 @@ func (p *CLI) Confirm(message, help string) (val bool, err error) {
 	return
 }
 
-func (p *CLI) SelectOne(message, help string, options []string) (val string, err error) {
+func (p *CLI) SelectOne(message, help string, options []string, defaultVal string) (val string, err error) {
 	prompt := &survey.Select{
 		Message: message,
 		Options: options,
 		Help:    help,
 	}
+	// survey treats empty string as a valid default, so set it only when specified
+	if defaultVal != "" {
+		prompt.Default = defaultVal
+	}
 	err = survey.AskOne(prompt, &val,
 		survey.WithIcons(setDefaultStyle),
 		survey.WithStdio(p.Stdin, p.Stdout, p.Stderr),
@@ -74,14 +192,24 @@ func (p *CLI) SelectOne(message, help string, options []string) (val string, err
 	return
 }
 
-func (p *CLI) SelectMultiple(message, help string, options []string) ([]string, error) {
+func (p *CLI) SelectMultiple(message, help string, options []string, defaultVal []string, validate func(val []string) error) ([]string, error) {
 	var val []string
 	prompt := &survey.MultiSelect{
 		Message: message,
 		Options: options,
 		Help:    help,
+		Default: defaultVal,
+	}
+	validator := func(answer interface{}) error {
+		list := answer.([]core.OptionAnswer)
+		selected := make([]string, len(list))
+		for i, opt := range list {
+			selected[i] = opt.Value
+		}
+		return validate(selected)
 	}
 	err := survey.AskOne(prompt, &val,
+		survey.WithValidator(validator),
 		survey.WithIcons(setDefaultStyle),
 		survey.WithStdio(p.Stdin, p.Stdout, p.Stderr),
 	)